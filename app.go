@@ -4,11 +4,16 @@ import (
 	"context"
 	"encoding/base32"
 	"encoding/base64"
+	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
+	"sync"
+	"time"
 
 	"google-authenticator/internal/migration"
+	"google-authenticator/internal/ntp"
 	"google-authenticator/internal/otp"
 	"google-authenticator/internal/qrcode"
 	"google-authenticator/internal/storage"
@@ -20,21 +25,37 @@ import (
 
 // App struct
 type App struct {
-	ctx context.Context
-	db  *storage.Database
+	ctx     context.Context
+	db      *storage.Database
+	dataDir string
+
+	// 多页迁移码导入会话
+	batchMu      sync.Mutex
+	batchSession *migration.BatchSession
+
+	// 导入预览暂存区
+	stageMu sync.Mutex
+	staged  []StagedAccount
 }
 
 // NewApp creates a new App application struct
-func NewApp() *App {
-	return &App{}
+func NewApp(dataDir string) *App {
+	return &App{dataDir: dataDir}
 }
 
 // startup is called when the app starts
 func (a *App) startup(ctx context.Context) {
 	a.ctx = ctx
 
+	// 旧版本的数据库保存在程序目录下，首次使用新数据目录时迁移过来
+	if migrated, err := storage.MigrateLegacyDataDir(a.dataDir); err != nil {
+		runtime.LogError(ctx, fmt.Sprintf("Failed to migrate legacy data directory: %v", err))
+	} else if migrated {
+		runtime.LogInfo(ctx, fmt.Sprintf("Migrated legacy database to %s", a.dataDir))
+	}
+
 	// 初始化数据库
-	db, err := storage.NewDatabase()
+	db, err := storage.NewDatabase(a.dataDir)
 	if err != nil {
 		runtime.LogError(ctx, fmt.Sprintf("Failed to initialize database: %v", err))
 		return
@@ -52,6 +73,8 @@ func (a *App) startup(ctx context.Context) {
 		// 无密码保护，使用设备密钥解锁
 		if err := db.UnlockWithDeviceKey(); err != nil {
 			runtime.LogError(ctx, fmt.Sprintf("Failed to unlock database: %v", err))
+		} else {
+			a.applyTimeSettings()
 		}
 	}
 	// 如果有密码保护，等待前端调用 Unlock
@@ -62,6 +85,9 @@ func (a *App) startup(ctx context.Context) {
 		releaseLock()
 		os.Exit(0)
 	})
+
+	// 定时快照
+	go a.runSnapshotSchedule(ctx)
 }
 
 // beforeClose 关闭时始终最小化到托盘
@@ -110,6 +136,14 @@ func (a *App) CloseDB() {
 	}
 }
 
+// GetDataDir 获取数据目录及是否为便携模式
+func (a *App) GetDataDir() map[string]interface{} {
+	return map[string]interface{}{
+		"path":     a.dataDir,
+		"portable": storage.IsPortableDir(a.dataDir),
+	}
+}
+
 // GetDatabaseStatus 获取数据库状态（用于调试）
 func (a *App) GetDatabaseStatus() map[string]interface{} {
 	if a.db == nil {
@@ -181,7 +215,52 @@ func (a *App) Unlock(password string) bool {
 	if a.db == nil {
 		return false
 	}
-	return a.db.Unlock(password) == nil
+	if err := a.db.Unlock(password); err != nil {
+		return false
+	}
+	a.applyTimeSettings()
+	return true
+}
+
+// defaultUnlockMillis 校准密钥派生参数时的默认目标解锁耗时
+const defaultUnlockMillis = 500
+
+// KDFSettings 密钥派生参数
+type KDFSettings struct {
+	Current storage.KDFParams `json:"current"` // 当前密钥使用的参数
+	Target  storage.KDFParams `json:"target"`  // 下次设置密码或解锁时使用的参数
+}
+
+// GetKDFSettings 获取当前与目标密钥派生参数
+func (a *App) GetKDFSettings() KDFSettings {
+	if a.db == nil {
+		return KDFSettings{Current: storage.DefaultKDFParams(), Target: storage.DefaultKDFParams()}
+	}
+	current, err := a.db.KDFParams()
+	if err != nil {
+		current = storage.DefaultKDFParams()
+	}
+	target, err := a.db.TargetKDFParams()
+	if err != nil {
+		target = storage.DefaultKDFParams()
+	}
+	return KDFSettings{Current: current, Target: target}
+}
+
+// CalibrateKDF 按本机性能计算使解锁耗时接近 targetMillis 毫秒的参数（不保存）
+func (a *App) CalibrateKDF(targetMillis int) storage.KDFParams {
+	if targetMillis <= 0 {
+		targetMillis = defaultUnlockMillis
+	}
+	return storage.CalibrateKDFParams(time.Duration(targetMillis) * time.Millisecond)
+}
+
+// SetKDFParams 保存目标参数，已启用密码时在下一次解锁时重新派生密钥
+func (a *App) SetKDFParams(params storage.KDFParams) bool {
+	if a.db == nil {
+		return false
+	}
+	return a.db.SetTargetKDFParams(params) == nil
 }
 
 // === 设置管理 ===
@@ -246,12 +325,62 @@ func (a *App) GetAutoLockMinutes() int {
 
 // ImportResult represents the result of an import operation
 type ImportResult struct {
-	Success  bool          `json:"success"`
-	Message  string        `json:"message"`
-	Count    int           `json:"count"`
+	Success       bool                  `json:"success"`
+	Message       string                `json:"message"`
+	Count         int                   `json:"count"`
+	Accounts      []otp.Account         `json:"accounts"`
+	Warnings      []string              `json:"warnings,omitempty"`
+	Details       []ImportDetail        `json:"details,omitempty"`        // 逐个账户的处理结果
+	NeedsPassword bool                  `json:"needs_password,omitempty"` // 备份已加密或密码错误
+	LineErrors    []migration.LineError `json:"line_errors,omitempty"`    // 文本导入中无法解析的行
+	Pending       bool                  `json:"pending"`                  // 多页迁移码尚未扫描完整
+	Batch         *MigrationBatchStatus `json:"batch,omitempty"`          // 多页迁移码导入进度
+}
+
+// 导入账户的处理动作
+const (
+	ImportActionAdded       = "added"
+	ImportActionSkipped     = "skipped"
+	ImportActionOverwritten = "overwritten"
+	ImportActionMerged      = "merged"
+	ImportActionFailed      = "failed"
+)
+
+// ImportDetail 单个导入账户的处理结果
+type ImportDetail struct {
+	Name       string `json:"name"`
+	Issuer     string `json:"issuer"`
+	Action     string `json:"action"`
+	AccountID  string `json:"account_id,omitempty"`  // 写入或保留的账户 ID
+	Match      string `json:"match,omitempty"`       // 重复判定依据：secret / issuer_name
+	ExistingID string `json:"existing_id,omitempty"` // 与之重复的已有账户 ID
+	Error      string `json:"error,omitempty"`
+	Reason     string `json:"reason,omitempty"` // 未按所选策略处理的原因
+}
+
+// DuplicateGroup 一组互相重复的账户
+type DuplicateGroup struct {
+	Match    string        `json:"match"`
 	Accounts []otp.Account `json:"accounts"`
 }
 
+// MergeDuplicatesResult 合并重复账户的结果
+type MergeDuplicatesResult struct {
+	Success bool     `json:"success"`
+	Message string   `json:"message"`
+	Count   int      `json:"count"`             // 被删除的重复账户数
+	Skipped []string `json:"skipped,omitempty"` // 密钥与保留账户不同而未删除的账户 ID
+}
+
+// MigrationBatchStatus 多页迁移码导入进度（页码从 1 开始）
+type MigrationBatchStatus struct {
+	Active    bool  `json:"active"`
+	BatchID   int32 `json:"batch_id"`
+	BatchSize int   `json:"batch_size"`
+	Scanned   []int `json:"scanned"`
+	Missing   []int `json:"missing"`
+}
+
 // GenerateCodeResult represents a generated OTP code
 type GenerateCodeResult struct {
 	Code      string `json:"code"`
@@ -259,11 +388,44 @@ type GenerateCodeResult struct {
 	Progress  int    `json:"progress"`
 }
 
+// OCRAResponseResult OCRA 挑战应答结果
+type OCRAResponseResult struct {
+	Success  bool   `json:"success"`
+	Response string `json:"response"`
+	Message  string `json:"message"`
+}
+
 // ExportQRResult represents an exported QR code
 type ExportQRResult struct {
-	Success   bool   `json:"success"`
-	Message   string `json:"message"`
-	QRCodeURL string `json:"qr_code_url"` // Base64 data URL
+	Success   bool     `json:"success"`
+	Message   string   `json:"message"`
+	QRCodeURL string   `json:"qr_code_url"`       // Base64 data URL
+	Skipped   []string `json:"skipped,omitempty"` // 无法导出的账户，格式为“账户名: 原因”
+}
+
+// ExportQRBatchResult represents a multi-page migration export
+type ExportQRBatchResult struct {
+	Success bool           `json:"success"`
+	Message string         `json:"message"`
+	Count   int            `json:"count"`
+	QRCodes []ExportQRPage `json:"qr_codes"`          // 按页序排列
+	Skipped []string       `json:"skipped,omitempty"` // 无法导出的账户，格式为“账户名: 原因”
+}
+
+// ExportQRPage 多页迁移码中的一页
+type ExportQRPage struct {
+	Index     int    `json:"index"` // 页码，从 1 开始
+	Total     int    `json:"total"`
+	Count     int    `json:"count"` // 本页包含的账户数
+	QRCodeURL string `json:"qr_code_url"`
+}
+
+// ExportFileResult represents an export written to a file
+type ExportFileResult struct {
+	Success bool   `json:"success"`
+	Message string `json:"message"`
+	Count   int    `json:"count"`
+	Path    string `json:"path,omitempty"` // 保存路径
 }
 
 // === 账户操作 ===
@@ -280,7 +442,11 @@ func storageAccountToOTP(acc storage.Account) otp.Account {
 		Type:      acc.Type,
 		Counter:   acc.Counter,
 		Period:    acc.Period,
+		Pin:       acc.Pin,
+		OcraSuite: acc.OcraSuite,
 		Group:     acc.Group,
+		Note:      acc.Note,
+		Icon:      acc.Icon,
 	}
 }
 
@@ -296,7 +462,11 @@ func otpAccountToStorage(acc otp.Account) storage.Account {
 		Type:      acc.Type,
 		Counter:   acc.Counter,
 		Period:    acc.Period,
+		Pin:       acc.Pin,
+		OcraSuite: acc.OcraSuite,
 		Group:     acc.Group,
+		Note:      acc.Note,
+		Icon:      acc.Icon,
 	}
 }
 
@@ -318,13 +488,94 @@ func (a *App) GetAllAccounts() []otp.Account {
 	return accounts
 }
 
+// migrationParamToAccount 将迁移参数转换为 otp.Account
+func migrationParamToAccount(p *migration.OtpParameters) otp.Account {
+	acc := p.Account()
+	acc.ID = uuid.New().String()
+	return acc
+}
+
+// formatPages 将页码列表格式化为 "1、2、3"
+func formatPages(pages []int) string {
+	parts := make([]string, len(pages))
+	for i, p := range pages {
+		parts[i] = fmt.Sprintf("%d", p)
+	}
+	return strings.Join(parts, "、")
+}
+
+// batchStatusLocked 获取当前多页导入进度，调用方需持有 batchMu
+func (a *App) batchStatusLocked() MigrationBatchStatus {
+	if a.batchSession == nil {
+		return MigrationBatchStatus{Scanned: []int{}, Missing: []int{}}
+	}
+
+	status := MigrationBatchStatus{
+		Active:    true,
+		BatchID:   a.batchSession.BatchID,
+		BatchSize: a.batchSession.BatchSize,
+		Scanned:   make([]int, 0),
+		Missing:   make([]int, 0),
+	}
+	for _, i := range a.batchSession.Scanned() {
+		status.Scanned = append(status.Scanned, i+1)
+	}
+	for _, i := range a.batchSession.Missing() {
+		status.Missing = append(status.Missing, i+1)
+	}
+	return status
+}
+
+// GetMigrationBatchStatus 获取多页迁移码导入进度
+func (a *App) GetMigrationBatchStatus() MigrationBatchStatus {
+	a.batchMu.Lock()
+	defer a.batchMu.Unlock()
+	return a.batchStatusLocked()
+}
+
+// CancelMigrationBatch 放弃当前多页迁移码导入会话
+func (a *App) CancelMigrationBatch() bool {
+	a.batchMu.Lock()
+	defer a.batchMu.Unlock()
+
+	if a.batchSession == nil {
+		return false
+	}
+	a.batchSession = nil
+	return true
+}
+
+// collectMigrationBatch 将一页迁移数据加入导入会话
+// 所有页扫描完成后返回全部账户；否则返回 nil 及当前进度
+func (a *App) collectMigrationBatch(params []*migration.OtpParameters, info migration.BatchInfo) ([]*migration.OtpParameters, MigrationBatchStatus, error) {
+	a.batchMu.Lock()
+	defer a.batchMu.Unlock()
+
+	if a.batchSession == nil {
+		a.batchSession = migration.NewBatchSession(info)
+	}
+	if _, err := a.batchSession.AddPage(info, params); err != nil {
+		return nil, a.batchStatusLocked(), err
+	}
+
+	status := a.batchStatusLocked()
+	if !a.batchSession.Complete() {
+		return nil, status, nil
+	}
+
+	all := a.batchSession.Accounts()
+	a.batchSession = nil
+	return all, status, nil
+}
+
 // ImportFromMigrationURI imports accounts from Google Authenticator migration URI
+// 多页迁移码会在所有页扫描完成后统一导入
 func (a *App) ImportFromMigrationURI(uri string) ImportResult {
 	if a.db == nil {
 		return ImportResult{Success: false, Message: "数据库未初始化"}
 	}
 
-	params, err := migration.ParseMigrationURISimple(uri)
+	params, info, err := migration.ParseMigrationURISimple(uri)
 	if err != nil {
 		return ImportResult{
 			Success: false,
@@ -332,42 +583,47 @@ func (a *App) ImportFromMigrationURI(uri string) ImportResult {
 		}
 	}
 
-	accounts := make([]otp.Account, 0)
-	for _, p := range params {
-		acc := otp.Account{
-			ID:        uuid.New().String(),
-			Name:      p.Name,
-			Issuer:    p.Issuer,
-			Secret:    base32.StdEncoding.EncodeToString(p.Secret),
-			Algorithm: p.Algorithm.String(),
-			Digits:    p.Digits.ToInt(),
-			Type:      p.Type.String(),
-			Counter:   p.Counter,
-			Period:    30,
+	var batch *MigrationBatchStatus
+	if info.IsMultiBatch(len(params)) {
+		all, status, err := a.collectMigrationBatch(params, info)
+		if err != nil {
+			return ImportResult{
+				Success: false,
+				Message: fmt.Sprintf("该迁移码不属于当前批次，请先完成或取消正在进行的导入: %v", err),
+				Batch:   &status,
+			}
 		}
-
-		// 保存到数据库
-		if err := a.db.SaveAccount(otpAccountToStorage(acc)); err != nil {
-			continue
+		if all == nil {
+			return ImportResult{
+				Success: true,
+				Pending: true,
+				Message: fmt.Sprintf("已扫描 %d/%d 页，还缺第 %s 页",
+					len(status.Scanned), status.BatchSize, formatPages(status.Missing)),
+				Batch: &status,
+			}
 		}
-		accounts = append(accounts, acc)
+		params = all
+		status.Active = false
+		batch = &status
 	}
 
-	return ImportResult{
-		Success:  true,
-		Message:  fmt.Sprintf("成功导入 %d 个账户", len(accounts)),
-		Count:    len(accounts),
-		Accounts: accounts,
+	accounts := make([]otp.Account, 0, len(params))
+	for _, p := range params {
+		accounts = append(accounts, migrationParamToAccount(p))
 	}
+
+	result := a.saveImportedAccounts(accounts, a.duplicatePolicy())
+	result.Batch = batch
+	return result
 }
 
-// ImportFromStandardURI imports a single account from standard otpauth:// URI
+// ImportFromStandardURI imports a single account from standard otpauth:// URI (or steam:// URI)
 func (a *App) ImportFromStandardURI(uri string) ImportResult {
 	if a.db == nil {
 		return ImportResult{Success: false, Message: "数据库未初始化"}
 	}
 
-	param, err := migration.ParseOTPAuthURI(uri)
+	key, err := migration.ParseKeyURI(uri)
 	if err != nil {
 		return ImportResult{
 			Success: false,
@@ -375,40 +631,235 @@ func (a *App) ImportFromStandardURI(uri string) ImportResult {
 		}
 	}
 
-	acc := otp.Account{
-		ID:        uuid.New().String(),
-		Name:      param.Name,
-		Issuer:    param.Issuer,
-		Secret:    base32.StdEncoding.EncodeToString(param.Secret),
-		Algorithm: param.Algorithm.String(),
-		Digits:    param.Digits.ToInt(),
-		Type:      param.Type.String(),
-		Counter:   param.Counter,
-		Period:    30,
+	result := a.saveImportedAccounts([]otp.Account{key.Account()}, a.duplicatePolicy())
+	result.Warnings = append(result.Warnings, key.Warnings...)
+	return result
+}
+
+// duplicatePolicy 读取设置中的重复账户处理策略
+func (a *App) duplicatePolicy() storage.MergePolicy {
+	settings, _ := a.db.GetSettings()
+	return storage.ParseMergePolicy(settings.DuplicatePolicy)
+}
+
+// saveImportedAccounts 按重复策略逐个保存导入的账户并汇总结果
+// 所有导入来源最终都经由此处写入数据库
+func (a *App) saveImportedAccounts(accounts []otp.Account, policy storage.MergePolicy) ImportResult {
+	if len(accounts) > 0 {
+		if _, err := a.db.Snapshot(storage.SnapshotReasonImport); err != nil {
+			return ImportResult{
+				Success: false,
+				Message: fmt.Sprintf("导入前创建快照失败: %v", err),
+			}
+		}
 	}
 
-	// 保存到数据库
-	if err := a.db.SaveAccount(otpAccountToStorage(acc)); err != nil {
+	existing, err := a.db.GetAllAccounts()
+	if err != nil {
 		return ImportResult{
 			Success: false,
-			Message: fmt.Sprintf("保存失败: %v", err),
+			Message: fmt.Sprintf("读取现有账户失败: %v", err),
+		}
+	}
+
+	saved := make([]otp.Account, 0, len(accounts))
+	details := make([]ImportDetail, 0, len(accounts))
+	counts := make(map[string]int)
+	var warnings []string
+
+	for _, acc := range accounts {
+		if acc.ID == "" {
+			acc.ID = uuid.New().String()
+		}
+		incoming := otpAccountToStorage(acc)
+		detail := ImportDetail{Name: acc.Name, Issuer: acc.Issuer}
+
+		toSave := incoming
+		action := ImportActionAdded
+		if dup, match := storage.FindDuplicate(existing, incoming); dup != nil {
+			detail.Match = string(match)
+			detail.ExistingID = dup.ID
+			effective := storage.EffectivePolicy(*dup, incoming, match, policy)
+			if effective != policy {
+				detail.Reason = "密钥与同名的已有账户不同，已作为新账户保存，请确认后手动删除失效的一个"
+				warnings = append(warnings, fmt.Sprintf("%s: %s", acc.Name, detail.Reason))
+			}
+			switch effective {
+			case storage.PolicyOverwrite:
+				toSave = storage.MergeAccounts(*dup, incoming, policy)
+				action = ImportActionOverwritten
+			case storage.PolicyMerge:
+				toSave = storage.MergeAccounts(*dup, incoming, policy)
+				action = ImportActionMerged
+			case storage.PolicyKeepBoth:
+				// 作为新账户保存
+			default:
+				action = ImportActionSkipped
+			}
+		}
+
+		if action == ImportActionSkipped {
+			detail.Action = action
+			detail.AccountID = detail.ExistingID
+			details = append(details, detail)
+			counts[action]++
+			continue
+		}
+
+		// 保存到数据库
+		if err := a.db.SaveAccount(toSave); err != nil {
+			detail.Action = ImportActionFailed
+			detail.Error = err.Error()
+			details = append(details, detail)
+			counts[ImportActionFailed]++
+			continue
+		}
+
+		detail.Action = action
+		detail.AccountID = toSave.ID
+		details = append(details, detail)
+		counts[action]++
+		saved = append(saved, storageAccountToOTP(toSave))
+
+		// 更新已有账户列表，使同一批次内的重复项也能被识别
+		replaced := false
+		for i := range existing {
+			if existing[i].ID == toSave.ID {
+				existing[i] = toSave
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			existing = append(existing, toSave)
 		}
 	}
 
 	return ImportResult{
-		Success:  true,
-		Message:  fmt.Sprintf("成功添加账户: %s", acc.Name),
-		Count:    1,
-		Accounts: []otp.Account{acc},
+		Success:  counts[ImportActionFailed] < len(accounts) || len(accounts) == 0,
+		Message:  summarizeImport(counts),
+		Count:    len(saved),
+		Accounts: saved,
+		Warnings: warnings,
+		Details:  details,
 	}
 }
 
-// ImportFromQRCodeImage imports accounts from QR code image (base64 encoded)
-func (a *App) ImportFromQRCodeImage(base64Image string) ImportResult {
+// summarizeImport 生成导入结果摘要
+func summarizeImport(counts map[string]int) string {
+	var parts []string
+	if n := counts[ImportActionAdded]; n > 0 {
+		parts = append(parts, fmt.Sprintf("新增 %d 个", n))
+	}
+	if n := counts[ImportActionOverwritten]; n > 0 {
+		parts = append(parts, fmt.Sprintf("覆盖 %d 个", n))
+	}
+	if n := counts[ImportActionMerged]; n > 0 {
+		parts = append(parts, fmt.Sprintf("合并 %d 个", n))
+	}
+	if n := counts[ImportActionSkipped]; n > 0 {
+		parts = append(parts, fmt.Sprintf("跳过重复 %d 个", n))
+	}
+	if n := counts[ImportActionFailed]; n > 0 {
+		parts = append(parts, fmt.Sprintf("失败 %d 个", n))
+	}
+	if len(parts) == 0 {
+		return "没有可导入的账户"
+	}
+	return "导入完成：" + strings.Join(parts, "，")
+}
+
+// GetDuplicatePolicy 获取导入重复账户的处理策略
+func (a *App) GetDuplicatePolicy() string {
 	if a.db == nil {
-		return ImportResult{Success: false, Message: "数据库未初始化"}
+		return string(storage.PolicySkip)
+	}
+	return string(a.duplicatePolicy())
+}
+
+// SetDuplicatePolicy 设置导入重复账户的处理策略（skip / overwrite / keep_both / merge）
+func (a *App) SetDuplicatePolicy(policy string) bool {
+	if a.db == nil {
+		return false
+	}
+
+	settings, _ := a.db.GetSettings()
+	settings.DuplicatePolicy = string(storage.ParseMergePolicy(policy))
+	return a.db.SaveSettings(settings) == nil
+}
+
+// FindDuplicateAccounts 扫描现有账户中的重复项
+func (a *App) FindDuplicateAccounts() []DuplicateGroup {
+	if a.db == nil {
+		return []DuplicateGroup{}
+	}
+
+	accounts, err := a.db.GetAllAccounts()
+	if err != nil {
+		return []DuplicateGroup{}
+	}
+
+	groups := make([]DuplicateGroup, 0)
+	for _, g := range storage.FindDuplicateGroups(accounts) {
+		group := DuplicateGroup{Match: string(g.Match)}
+		for _, acc := range g.Accounts {
+			group.Accounts = append(group.Accounts, storageAccountToOTP(acc))
+		}
+		groups = append(groups, group)
+	}
+	return groups
+}
+
+// MergeDuplicateAccounts 保留 keepID 对应的账户，将密钥相同的重复账户的元数据合并进来后删除
+// 密钥不同的账户（如按发行者+账户名匹配的分组）不会被删除，在结果中列出
+func (a *App) MergeDuplicateAccounts(keepID string, duplicateIDs []string) MergeDuplicatesResult {
+	if a.db == nil {
+		return MergeDuplicatesResult{Success: false, Message: "数据库未初始化"}
+	}
+
+	keep, err := a.db.GetAccount(keepID)
+	if err != nil || keep == nil {
+		return MergeDuplicatesResult{Success: false, Message: "要保留的账户不存在"}
 	}
 
+	merged := *keep
+	var removable []string
+	var skipped []string
+	var skippedNames []string
+	for _, id := range duplicateIDs {
+		if id == keepID {
+			continue
+		}
+		dup, err := a.db.GetAccount(id)
+		if err != nil || dup == nil {
+			continue
+		}
+		if !storage.SameSecret(*keep, *dup) {
+			skipped = append(skipped, id)
+			skippedNames = append(skippedNames, dup.Name)
+			continue
+		}
+		merged = storage.MergeAccounts(merged, *dup, storage.PolicyMerge)
+		removable = append(removable, id)
+	}
+
+	count, err := a.db.MergeAccountInto(merged, removable)
+	if err != nil {
+		return MergeDuplicatesResult{Success: false, Message: fmt.Sprintf("合并失败: %v", err), Skipped: skipped}
+	}
+
+	message := fmt.Sprintf("已合并 %d 个重复账户", count)
+	if len(skipped) > 0 {
+		message += fmt.Sprintf("，%d 个账户密钥不同已保留：%s", len(skipped), strings.Join(skippedNames, "、"))
+	}
+	return MergeDuplicatesResult{Success: true, Message: message, Count: count, Skipped: skipped}
+}
+
+// errNoFileSelected 用户取消了文件选择
+var errNoFileSelected = errors.New("未选择文件")
+
+// scanQRCodeBase64 识别 base64 编码图片（可带 data URL 前缀）中的二维码
+func scanQRCodeBase64(base64Image string) (string, error) {
 	// Remove data URL prefix if present
 	if strings.HasPrefix(base64Image, "data:image") {
 		parts := strings.Split(base64Image, ",")
@@ -420,40 +871,19 @@ func (a *App) ImportFromQRCodeImage(base64Image string) ImportResult {
 	// Decode base64
 	imgData, err := base64.StdEncoding.DecodeString(base64Image)
 	if err != nil {
-		return ImportResult{
-			Success: false,
-			Message: fmt.Sprintf("图片解码失败: %v", err),
-		}
+		return "", fmt.Errorf("图片解码失败: %v", err)
 	}
 
 	// Scan QR code
 	uri, err := qrcode.ScanQRCodeFromBytes(imgData)
 	if err != nil {
-		return ImportResult{
-			Success: false,
-			Message: fmt.Sprintf("QR码识别失败: %v", err),
-		}
-	}
-
-	// Check URI type and import accordingly
-	if strings.HasPrefix(uri, "otpauth-migration://") {
-		return a.ImportFromMigrationURI(uri)
-	} else if strings.HasPrefix(uri, "otpauth://") {
-		return a.ImportFromStandardURI(uri)
-	} else {
-		return ImportResult{
-			Success: false,
-			Message: fmt.Sprintf("不支持的URI格式: %s", uri),
-		}
+		return "", fmt.Errorf("QR码识别失败: %v", err)
 	}
+	return uri, nil
 }
 
-// ImportFromFile opens a file dialog and imports QR code from selected image file
-func (a *App) ImportFromFile() ImportResult {
-	if a.db == nil {
-		return ImportResult{Success: false, Message: "数据库未初始化"}
-	}
-
+// scanQRCodeFromDialog 打开文件对话框并识别所选图片中的二维码
+func (a *App) scanQRCodeFromDialog() (string, error) {
 	// Open file dialog
 	file, err := runtime.OpenFileDialog(a.ctx, runtime.OpenDialogOptions{
 		Title: "选择二维码图片",
@@ -464,43 +894,33 @@ func (a *App) ImportFromFile() ImportResult {
 			},
 		},
 	})
-
 	if err != nil {
-		return ImportResult{
-			Success: false,
-			Message: fmt.Sprintf("打开文件失败: %v", err),
-		}
+		return "", fmt.Errorf("打开文件失败: %v", err)
 	}
-
 	if file == "" {
-		return ImportResult{
-			Success: false,
-			Message: "未选择文件",
-		}
+		return "", errNoFileSelected
 	}
 
 	// Read file
 	imgData, err := readFile(file)
 	if err != nil {
-		return ImportResult{
-			Success: false,
-			Message: fmt.Sprintf("读取文件失败: %v", err),
-		}
+		return "", fmt.Errorf("读取文件失败: %v", err)
 	}
 
 	// Scan QR code
 	uri, err := qrcode.ScanQRCodeFromBytes(imgData)
 	if err != nil {
-		return ImportResult{
-			Success: false,
-			Message: fmt.Sprintf("QR码识别失败: %v", err),
-		}
+		return "", fmt.Errorf("QR码识别失败: %v", err)
 	}
+	return uri, nil
+}
 
+// importURI 根据 URI 类型导入账户
+func (a *App) importURI(uri string) ImportResult {
 	// Check URI type and import accordingly
 	if strings.HasPrefix(uri, "otpauth-migration://") {
 		return a.ImportFromMigrationURI(uri)
-	} else if strings.HasPrefix(uri, "otpauth://") {
+	} else if strings.HasPrefix(uri, "otpauth://") || strings.HasPrefix(uri, "steam://") {
 		return a.ImportFromStandardURI(uri)
 	} else {
 		return ImportResult{
@@ -510,48 +930,1419 @@ func (a *App) ImportFromFile() ImportResult {
 	}
 }
 
-// readFile 读取文件内容
-func readFile(path string) ([]byte, error) {
-	return os.ReadFile(path)
-}
-
-// AddAccount adds a new account manually
-func (a *App) AddAccount(name, issuer, secret, algorithm, otpType string, digits, period int) ImportResult {
-	return a.AddAccountWithGroup(name, issuer, secret, algorithm, otpType, digits, period, "")
-}
-
-// AddAccountWithGroup adds a new account with group
-func (a *App) AddAccountWithGroup(name, issuer, secret, algorithm, otpType string, digits, period int, group string) ImportResult {
+// ImportFromQRCodeImage imports accounts from QR code image (base64 encoded)
+func (a *App) ImportFromQRCodeImage(base64Image string) ImportResult {
 	if a.db == nil {
 		return ImportResult{Success: false, Message: "数据库未初始化"}
 	}
 
-	acc := otp.Account{
-		ID:        uuid.New().String(),
-		Name:      name,
-		Issuer:    issuer,
-		Secret:    secret,
-		Algorithm: algorithm,
-		Digits:    digits,
-		Type:      otpType,
-		Period:    period,
-		Counter:   0,
-		Group:     group,
+	uri, err := scanQRCodeBase64(base64Image)
+	if err != nil {
+		return ImportResult{Success: false, Message: err.Error()}
 	}
+	return a.importURI(uri)
+}
 
-	// 保存到数据库
-	if err := a.db.SaveAccount(otpAccountToStorage(acc)); err != nil {
-		return ImportResult{
-			Success: false,
-			Message: fmt.Sprintf("保存失败: %v", err),
-		}
+// ImportFromFile opens a file dialog and imports QR code from selected image file
+func (a *App) ImportFromFile() ImportResult {
+	if a.db == nil {
+		return ImportResult{Success: false, Message: "数据库未初始化"}
 	}
 
-	return ImportResult{
-		Success:  true,
-		Message:  "账户添加成功",
-		Count:    1,
-		Accounts: []otp.Account{acc},
+	uri, err := a.scanQRCodeFromDialog()
+	if err != nil {
+		return ImportResult{Success: false, Message: err.Error()}
+	}
+	return a.importURI(uri)
+}
+
+// === 导入预览 ===
+
+// importEntry 解析得到、尚未写入数据库的账户
+type importEntry struct {
+	account  otp.Account
+	warnings []string
+}
+
+// StagedAccount 预览阶段暂存的账户
+type StagedAccount struct {
+	StageID    string      `json:"stage_id"`
+	Source     string      `json:"source"` // 来源描述，如 "迁移码 第 1/3 页"
+	Account    otp.Account `json:"account"`
+	Code       string      `json:"code"` // 当前验证码，便于与手机端核对
+	Warnings   []string    `json:"warnings"`
+	Match      string      `json:"match,omitempty"`       // 与已有账户重复的判定依据
+	ExistingID string      `json:"existing_id,omitempty"` // 与之重复的已有账户 ID
+}
+
+// ImportPreview 导入预览结果，Accounts 为当前暂存区中的全部账户
+type ImportPreview struct {
+	Success       bool                  `json:"success"`
+	Message       string                `json:"message"`
+	Added         int                   `json:"added"` // 本次新加入暂存区的账户数
+	Accounts      []StagedAccount       `json:"accounts"`
+	Warnings      []string              `json:"warnings,omitempty"`       // 被跳过的条目等文件级告警
+	NeedsPassword bool                  `json:"needs_password,omitempty"` // 备份已加密或密码错误
+	Pending       bool                  `json:"pending,omitempty"`        // 多页迁移码尚未扫描完整
+	Batch         *MigrationBatchStatus `json:"batch,omitempty"`          // 多页迁移码导入进度
+}
+
+// parseImportURI 解析迁移码或标准 otpauth URI，不写入数据库
+// 多页迁移码经由导入会话收集，未扫描完整时 entries 为 nil，batch 为当前进度
+func (a *App) parseImportURI(uri string) (entries []importEntry, source string, batch *MigrationBatchStatus, err error) {
+	if strings.HasPrefix(uri, "otpauth-migration://") {
+		params, info, err := migration.ParseMigrationURISimple(uri)
+		if err != nil {
+			return nil, "", nil, err
+		}
+		source := "迁移码"
+		if info.IsMultiBatch(len(params)) {
+			all, status, err := a.collectMigrationBatch(params, info)
+			if err != nil {
+				return nil, "", &status, fmt.Errorf("该迁移码不属于当前批次，请先完成或取消正在进行的导入: %v", err)
+			}
+			if all == nil {
+				return nil, "", &status, nil
+			}
+			params = all
+			status.Active = false
+			batch = &status
+			source = fmt.Sprintf("迁移码 共 %d 页", info.BatchSize)
+		}
+		entries := make([]importEntry, 0, len(params))
+		for _, p := range params {
+			entries = append(entries, importEntry{account: migrationParamToAccount(p)})
+		}
+		return entries, source, batch, nil
+	}
+
+	if strings.HasPrefix(uri, "otpauth://") || strings.HasPrefix(uri, "steam://") {
+		key, err := migration.ParseKeyURI(uri)
+		if err != nil {
+			return nil, "", nil, err
+		}
+		acc := key.Account()
+		acc.ID = uuid.New().String()
+		return []importEntry{{account: acc, warnings: key.Warnings}}, "otpauth URI", nil, nil
+	}
+
+	return nil, "", nil, fmt.Errorf("不支持的URI格式: %s", uri)
+}
+
+// accountWarnings 检查账户参数中可能导致验证码不一致的情况
+func accountWarnings(acc otp.Account) []string {
+	var warnings []string
+	if _, err := otp.DecodeSecret(acc.Secret); err != nil {
+		warnings = append(warnings, fmt.Sprintf("密钥无效: %v", err))
+	}
+	if strings.TrimSpace(acc.Name) == "" {
+		warnings = append(warnings, "账户名为空")
+	}
+	switch strings.ToUpper(acc.Type) {
+	case otp.TypeSteam:
+		warnings = append(warnings, "Steam 令牌无法导出到 Google Authenticator")
+		return warnings
+	case otp.TypeOCRA:
+		suite, err := otp.ParseOCRASuite(acc.OcraSuite)
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("OCRA 套件无效: %v", err))
+		} else if suite.PinHash != "" && acc.Pin == "" {
+			warnings = append(warnings, "缺少 PIN")
+		}
+		warnings = append(warnings, "OCRA 令牌无法导出到 Google Authenticator")
+		return warnings
+	case otp.TypeYandex, otp.TypeMOTP:
+		if acc.Pin == "" {
+			warnings = append(warnings, "缺少 PIN")
+		}
+		warnings = append(warnings, fmt.Sprintf("%s 令牌无法导出到 Google Authenticator", acc.Type))
+		return warnings
+	}
+	if acc.Digits != 6 && acc.Digits != 8 {
+		warnings = append(warnings, fmt.Sprintf("非标准验证码位数: %d", acc.Digits))
+	}
+	if strings.ToUpper(acc.Type) != "HOTP" && acc.Period != 30 {
+		warnings = append(warnings, fmt.Sprintf("非标准刷新周期: %d 秒", acc.Period))
+	}
+	if alg := strings.ToUpper(acc.Algorithm); alg != "" && alg != "SHA1" {
+		warnings = append(warnings, fmt.Sprintf("算法 %s 在部分应用中不受支持", alg))
+	}
+	return warnings
+}
+
+// stageEntries 将解析结果加入暂存区并返回完整预览
+func (a *App) stageEntries(source string, entries []importEntry) ImportPreview {
+	existing, _ := a.db.GetAllAccounts()
+
+	a.stageMu.Lock()
+	defer a.stageMu.Unlock()
+
+	for _, e := range entries {
+		acc := e.account
+		if acc.ID == "" {
+			acc.ID = uuid.New().String()
+		}
+
+		staged := StagedAccount{
+			StageID:  uuid.New().String(),
+			Source:   source,
+			Account:  acc,
+			Code:     generateAccountCode(otpAccountToStorage(acc)).Code,
+			Warnings: append(append([]string{}, e.warnings...), accountWarnings(acc)...),
+		}
+		if dup, match := storage.FindDuplicate(existing, otpAccountToStorage(acc)); dup != nil {
+			staged.Match = string(match)
+			staged.ExistingID = dup.ID
+			staged.Warnings = append(staged.Warnings, fmt.Sprintf("与已有账户重复: %s", dup.Name))
+		} else {
+			for _, other := range a.staged {
+				if dup, _ := storage.FindDuplicate([]storage.Account{otpAccountToStorage(other.Account)}, otpAccountToStorage(acc)); dup != nil {
+					staged.Warnings = append(staged.Warnings, fmt.Sprintf("与暂存区中的账户重复: %s", other.Account.Name))
+					break
+				}
+			}
+		}
+		a.staged = append(a.staged, staged)
+	}
+
+	return ImportPreview{
+		Success:  true,
+		Message:  fmt.Sprintf("已解析 %d 个账户，暂存区共 %d 个", len(entries), len(a.staged)),
+		Added:    len(entries),
+		Accounts: append([]StagedAccount{}, a.staged...),
+	}
+}
+
+// previewURI 解析 URI 并加入暂存区，多页迁移码在所有页扫描完成后才整体加入
+func (a *App) previewURI(uri string) ImportPreview {
+	entries, source, batch, err := a.parseImportURI(uri)
+	if err != nil {
+		return ImportPreview{Success: false, Message: fmt.Sprintf("解析失败: %v", err), Batch: batch}
+	}
+	if batch != nil && batch.Active {
+		preview := a.GetStagedImport()
+		preview.Pending = true
+		preview.Batch = batch
+		preview.Message = fmt.Sprintf("已扫描 %d/%d 页，还缺第 %s 页",
+			len(batch.Scanned), batch.BatchSize, formatPages(batch.Missing))
+		return preview
+	}
+
+	preview := a.stageEntries(source, entries)
+	preview.Batch = batch
+	return preview
+}
+
+// PreviewImportURI 解析迁移码或 otpauth URI 到暂存区，不写入数据库
+func (a *App) PreviewImportURI(uri string) ImportPreview {
+	if a.db == nil {
+		return ImportPreview{Success: false, Message: "数据库未初始化"}
+	}
+	return a.previewURI(strings.TrimSpace(uri))
+}
+
+// PreviewImportQRCodeImage 识别二维码图片（base64）并加入暂存区
+func (a *App) PreviewImportQRCodeImage(base64Image string) ImportPreview {
+	if a.db == nil {
+		return ImportPreview{Success: false, Message: "数据库未初始化"}
+	}
+
+	uri, err := scanQRCodeBase64(base64Image)
+	if err != nil {
+		return ImportPreview{Success: false, Message: err.Error()}
+	}
+	return a.previewURI(uri)
+}
+
+// PreviewImportFile 选择二维码图片文件并加入暂存区
+func (a *App) PreviewImportFile() ImportPreview {
+	if a.db == nil {
+		return ImportPreview{Success: false, Message: "数据库未初始化"}
+	}
+
+	uri, err := a.scanQRCodeFromDialog()
+	if err != nil {
+		return ImportPreview{Success: false, Message: err.Error()}
+	}
+	return a.previewURI(uri)
+}
+
+// GetStagedImport 获取当前暂存区内容（重新计算验证码）
+func (a *App) GetStagedImport() ImportPreview {
+	a.stageMu.Lock()
+	defer a.stageMu.Unlock()
+
+	accounts := make([]StagedAccount, len(a.staged))
+	for i, staged := range a.staged {
+		staged.Code = generateAccountCode(otpAccountToStorage(staged.Account)).Code
+		accounts[i] = staged
+	}
+	return ImportPreview{
+		Success:  true,
+		Message:  fmt.Sprintf("暂存区共 %d 个账户", len(accounts)),
+		Accounts: accounts,
+	}
+}
+
+// DiscardStagedImport 清空暂存区
+func (a *App) DiscardStagedImport() {
+	a.stageMu.Lock()
+	defer a.stageMu.Unlock()
+	a.staged = nil
+}
+
+// CommitStagedImport 将暂存区中选中的账户写入数据库，并清空暂存区
+// policy 为空时使用设置中的重复账户处理策略
+func (a *App) CommitStagedImport(stageIDs []string, policy string) ImportResult {
+	if a.db == nil {
+		return ImportResult{Success: false, Message: "数据库未初始化"}
+	}
+
+	a.stageMu.Lock()
+	selected := make(map[string]bool, len(stageIDs))
+	for _, id := range stageIDs {
+		selected[id] = true
+	}
+	var accounts []otp.Account
+	var committing []string
+	for _, staged := range a.staged {
+		if selected[staged.StageID] {
+			accounts = append(accounts, staged.Account)
+			committing = append(committing, staged.StageID)
+		}
+	}
+	a.stageMu.Unlock()
+
+	if len(accounts) == 0 {
+		return ImportResult{Success: false, Message: "没有选中任何账户"}
+	}
+
+	mergePolicy := a.duplicatePolicy()
+	if policy != "" {
+		mergePolicy = storage.ParseMergePolicy(policy)
+	}
+	result := a.saveImportedAccounts(accounts, mergePolicy)
+	if !result.Success {
+		return result
+	}
+
+	// 只移出已处理的暂存项，保存失败的和未选中的继续留在暂存区
+	done := make(map[string]bool, len(committing))
+	for i, detail := range result.Details {
+		if i < len(committing) && detail.Action != ImportActionFailed {
+			done[committing[i]] = true
+		}
+	}
+	a.stageMu.Lock()
+	remaining := a.staged[:0]
+	for _, staged := range a.staged {
+		if !done[staged.StageID] {
+			remaining = append(remaining, staged)
+		}
+	}
+	a.staged = remaining
+	a.stageMu.Unlock()
+
+	return result
+}
+
+// === 第三方备份导入 ===
+
+// backupFormat 第三方备份文件格式
+type backupFormat struct {
+	name    string
+	filters []runtime.FileFilter
+	parse   func(data []byte, password string) ([]otp.Account, []string, error)
+}
+
+// backupFormats 支持的第三方备份格式，键为前端传入的格式标识
+var backupFormats = map[string]backupFormat{
+	"aegis": {
+		name: "Aegis",
+		filters: []runtime.FileFilter{
+			{DisplayName: "Aegis 备份 (*.json)", Pattern: "*.json"},
+		},
+		parse: migration.ParseAegisVault,
+	},
+	"andotp": {
+		name: "andOTP",
+		filters: []runtime.FileFilter{
+			{DisplayName: "andOTP 备份 (*.json;*.aes)", Pattern: "*.json;*.aes"},
+		},
+		parse: migration.ParseAndOTPBackup,
+	},
+	"2fas": {
+		name: "2FAS",
+		filters: []runtime.FileFilter{
+			{DisplayName: "2FAS 备份 (*.2fas)", Pattern: "*.2fas;*.json"},
+		},
+		parse: migration.ParseTwoFASBackup,
+	},
+	"bitwarden": {
+		name: "Bitwarden",
+		filters: []runtime.FileFilter{
+			{DisplayName: "Bitwarden 导出 (*.json)", Pattern: "*.json"},
+		},
+		parse: migration.ParseBitwardenExport,
+	},
+	"freeotpplus": {
+		name: "FreeOTP+",
+		filters: []runtime.FileFilter{
+			{DisplayName: "FreeOTP+ 导出 (*.json)", Pattern: "*.json"},
+		},
+		parse: migration.ParseFreeOTPPlusBackup,
+	},
+	"authpro": {
+		name: "Authenticator Pro",
+		filters: []runtime.FileFilter{
+			{DisplayName: "Authenticator Pro 备份 (*.authpro;*.json)", Pattern: "*.authpro;*.json"},
+		},
+		parse: migration.ParseAuthenticatorProBackup,
+	},
+	"otpauth": {
+		name: "otpauth URI 列表",
+		filters: []runtime.FileFilter{
+			{DisplayName: "文本文件 (*.txt)", Pattern: "*.txt"},
+		},
+		parse: migration.ParseURIListFile,
+	},
+	"keepass": {
+		name: "KeePass",
+		filters: []runtime.FileFilter{
+			{DisplayName: "KeePass 数据库 (*.kdbx)", Pattern: "*.kdbx"},
+		},
+		parse: migration.ParseKeePassDatabase,
+	},
+	"gabak": {
+		name: "便携备份",
+		filters: []runtime.FileFilter{
+			{DisplayName: "便携备份 (*.gabak)", Pattern: "*" + storage.BackupFileExt},
+		},
+		parse: parsePortableBackup,
+	},
+}
+
+// lookupBackupFormat 按格式标识查找备份格式
+func lookupBackupFormat(format string) (backupFormat, error) {
+	f, ok := backupFormats[format]
+	if !ok {
+		return backupFormat{}, fmt.Errorf("不支持的备份格式: %s", format)
+	}
+	return f, nil
+}
+
+// parseBackup 按格式解析备份数据
+func parseBackup(f backupFormat, data []byte, password string) ([]importEntry, []string, error) {
+	accounts, warnings, err := f.parse(data, password)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	entries := make([]importEntry, 0, len(accounts))
+	for _, acc := range accounts {
+		entries = append(entries, importEntry{account: acc})
+	}
+	return entries, warnings, nil
+}
+
+// backupErrorMessage 将解析错误转换为提示信息
+func backupErrorMessage(err error) (string, bool) {
+	switch {
+	case errors.Is(err, migration.ErrPasswordRequired):
+		return "该备份已加密，请输入密码", true
+	case errors.Is(err, migration.ErrWrongPassword):
+		return "密码错误或备份文件已损坏", true
+	default:
+		return fmt.Sprintf("解析失败: %v", err), false
+	}
+}
+
+// decodeBase64Data 解码 base64 数据（可带 data URL 前缀）
+func decodeBase64Data(data string) ([]byte, error) {
+	if strings.HasPrefix(data, "data:") {
+		if i := strings.Index(data, ","); i >= 0 {
+			data = data[i+1:]
+		}
+	}
+	return base64.StdEncoding.DecodeString(data)
+}
+
+// openBackupFile 打开文件对话框并读取所选备份文件
+func (a *App) openBackupFile(f backupFormat) ([]byte, error) {
+	file, err := runtime.OpenFileDialog(a.ctx, runtime.OpenDialogOptions{
+		Title:   fmt.Sprintf("选择 %s 备份文件", f.name),
+		Filters: f.filters,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("打开文件失败: %v", err)
+	}
+	if file == "" {
+		return nil, errNoFileSelected
+	}
+
+	data, err := readFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("读取文件失败: %v", err)
+	}
+	return data, nil
+}
+
+// importBackup 解析备份数据并写入数据库
+func (a *App) importBackup(f backupFormat, data []byte, password string) ImportResult {
+	entries, warnings, err := parseBackup(f, data, password)
+	if err != nil {
+		message, needsPassword := backupErrorMessage(err)
+		return ImportResult{Success: false, Message: message, NeedsPassword: needsPassword}
+	}
+
+	accounts := make([]otp.Account, 0, len(entries))
+	for _, e := range entries {
+		accounts = append(accounts, e.account)
+	}
+
+	result := a.saveImportedAccounts(accounts, a.duplicatePolicy())
+	result.Warnings = append(result.Warnings, warnings...)
+	return result
+}
+
+// previewBackup 解析备份数据并加入暂存区
+func (a *App) previewBackup(f backupFormat, data []byte, password string) ImportPreview {
+	entries, warnings, err := parseBackup(f, data, password)
+	if err != nil {
+		message, needsPassword := backupErrorMessage(err)
+		return ImportPreview{Success: false, Message: message, NeedsPassword: needsPassword}
+	}
+
+	preview := a.stageEntries(f.name, entries)
+	preview.Warnings = warnings
+	return preview
+}
+
+// ImportFromBackupFile 选择第三方备份文件并导入
+// format: aegis / andotp / 2fas / bitwarden / freeotpplus / authpro / otpauth / keepass / gabak
+// Steam 的 .maFile 需要同目录的 manifest.json，使用 ImportFromSteamMaFile
+func (a *App) ImportFromBackupFile(format, password string) ImportResult {
+	if a.db == nil {
+		return ImportResult{Success: false, Message: "数据库未初始化"}
+	}
+
+	f, err := lookupBackupFormat(format)
+	if err != nil {
+		return ImportResult{Success: false, Message: err.Error()}
+	}
+	data, err := a.openBackupFile(f)
+	if err != nil {
+		return ImportResult{Success: false, Message: err.Error()}
+	}
+	return a.importBackup(f, data, password)
+}
+
+// ImportBackupData 导入前端读取的第三方备份文件内容（base64 编码）
+func (a *App) ImportBackupData(format, base64Data, password string) ImportResult {
+	if a.db == nil {
+		return ImportResult{Success: false, Message: "数据库未初始化"}
+	}
+
+	f, err := lookupBackupFormat(format)
+	if err != nil {
+		return ImportResult{Success: false, Message: err.Error()}
+	}
+	data, err := decodeBase64Data(base64Data)
+	if err != nil {
+		return ImportResult{Success: false, Message: fmt.Sprintf("文件解码失败: %v", err)}
+	}
+	return a.importBackup(f, data, password)
+}
+
+// PreviewBackupFile 选择第三方备份文件并加入暂存区
+func (a *App) PreviewBackupFile(format, password string) ImportPreview {
+	if a.db == nil {
+		return ImportPreview{Success: false, Message: "数据库未初始化"}
+	}
+
+	f, err := lookupBackupFormat(format)
+	if err != nil {
+		return ImportPreview{Success: false, Message: err.Error()}
+	}
+	data, err := a.openBackupFile(f)
+	if err != nil {
+		return ImportPreview{Success: false, Message: err.Error()}
+	}
+	return a.previewBackup(f, data, password)
+}
+
+// PreviewBackupData 将前端读取的第三方备份文件内容（base64 编码）加入暂存区
+func (a *App) PreviewBackupData(format, base64Data, password string) ImportPreview {
+	if a.db == nil {
+		return ImportPreview{Success: false, Message: "数据库未初始化"}
+	}
+
+	f, err := lookupBackupFormat(format)
+	if err != nil {
+		return ImportPreview{Success: false, Message: err.Error()}
+	}
+	data, err := decodeBase64Data(base64Data)
+	if err != nil {
+		return ImportPreview{Success: false, Message: fmt.Sprintf("文件解码失败: %v", err)}
+	}
+	return a.previewBackup(f, data, password)
+}
+
+// === 文本列表导入 ===
+
+// ImportFromText 导入每行一个 otpauth:// URI 的文本（也接受 otpauth-migration:// 行）
+func (a *App) ImportFromText(text string) ImportResult {
+	if a.db == nil {
+		return ImportResult{Success: false, Message: "数据库未初始化"}
+	}
+
+	accounts, warnings, lineErrors := migration.ParseURIList(text)
+	if len(accounts) == 0 {
+		message := "没有找到可导入的 URI"
+		if len(lineErrors) > 0 {
+			message = fmt.Sprintf("%d 行解析失败，没有可导入的账户", len(lineErrors))
+		}
+		return ImportResult{Success: false, Message: message, Warnings: warnings, LineErrors: lineErrors}
+	}
+
+	result := a.saveImportedAccounts(accounts, a.duplicatePolicy())
+	result.Warnings = append(result.Warnings, warnings...)
+	result.LineErrors = lineErrors
+	if len(lineErrors) > 0 {
+		result.Message = fmt.Sprintf("%s，%d 行解析失败", result.Message, len(lineErrors))
+	}
+	return result
+}
+
+// ImportFromTextFile 选择文本文件并按 URI 列表导入
+func (a *App) ImportFromTextFile() ImportResult {
+	if a.db == nil {
+		return ImportResult{Success: false, Message: "数据库未初始化"}
+	}
+
+	data, err := a.openBackupFile(backupFormats["otpauth"])
+	if err != nil {
+		return ImportResult{Success: false, Message: err.Error()}
+	}
+	return a.ImportFromText(string(data))
+}
+
+// PreviewImportText 将 URI 列表文本解析到暂存区，出错的行作为告警返回
+func (a *App) PreviewImportText(text string) ImportPreview {
+	if a.db == nil {
+		return ImportPreview{Success: false, Message: "数据库未初始化"}
+	}
+	return a.previewBackup(backupFormats["otpauth"], []byte(text), "")
+}
+
+// === KeePass ===
+
+// keePassFormat 返回使用指定密钥文件的 KeePass 格式，keyFilePath 为空时仅使用密码
+func keePassFormat(keyFilePath string) (backupFormat, error) {
+	f := backupFormats["keepass"]
+	if keyFilePath == "" {
+		return f, nil
+	}
+
+	keyFile, err := readFile(keyFilePath)
+	if err != nil {
+		return backupFormat{}, fmt.Errorf("读取密钥文件失败: %v", err)
+	}
+	f.parse = func(data []byte, password string) ([]otp.Account, []string, error) {
+		return migration.ParseKeePassDatabaseWithKeyFile(data, password, keyFile)
+	}
+	return f, nil
+}
+
+// SelectKeePassKeyFile 选择 KeePass 密钥文件，返回文件路径（取消时为空）
+func (a *App) SelectKeePassKeyFile() string {
+	file, err := runtime.OpenFileDialog(a.ctx, runtime.OpenDialogOptions{
+		Title: "选择 KeePass 密钥文件",
+		Filters: []runtime.FileFilter{
+			{DisplayName: "密钥文件 (*.keyx;*.key)", Pattern: "*.keyx;*.key"},
+			{DisplayName: "所有文件 (*.*)", Pattern: "*.*"},
+		},
+	})
+	if err != nil {
+		return ""
+	}
+	return file
+}
+
+// ImportFromKeePassFile 选择 KDBX 4 数据库并导入含 TOTP 的条目，keyFilePath 为空表示不使用密钥文件
+func (a *App) ImportFromKeePassFile(password, keyFilePath string) ImportResult {
+	if a.db == nil {
+		return ImportResult{Success: false, Message: "数据库未初始化"}
+	}
+
+	f, err := keePassFormat(keyFilePath)
+	if err != nil {
+		return ImportResult{Success: false, Message: err.Error()}
+	}
+	data, err := a.openBackupFile(f)
+	if err != nil {
+		return ImportResult{Success: false, Message: err.Error()}
+	}
+	return a.importBackup(f, data, password)
+}
+
+// PreviewKeePassFile 选择 KDBX 4 数据库并将含 TOTP 的条目加入暂存区
+func (a *App) PreviewKeePassFile(password, keyFilePath string) ImportPreview {
+	if a.db == nil {
+		return ImportPreview{Success: false, Message: "数据库未初始化"}
+	}
+
+	f, err := keePassFormat(keyFilePath)
+	if err != nil {
+		return ImportPreview{Success: false, Message: err.Error()}
+	}
+	data, err := a.openBackupFile(f)
+	if err != nil {
+		return ImportPreview{Success: false, Message: err.Error()}
+	}
+	return a.previewBackup(f, data, password)
+}
+
+// ExportToKeePassFile 将选中账户导出为新的 KDBX 4 数据库，分组按 "/" 拆分为 KeePass 子分组
+// password、keyFilePath 为 KeePass 数据库的凭据；启用了应用密码时需提供 currentPassword
+func (a *App) ExportToKeePassFile(accountIDs []string, password, keyFilePath, currentPassword string) ExportFileResult {
+	if a.db == nil {
+		return ExportFileResult{Success: false, Message: "数据库未初始化"}
+	}
+	if !a.checkExportPassword(currentPassword) {
+		return ExportFileResult{Success: false, Message: "密码错误"}
+	}
+
+	accounts := a.selectAccounts(accountIDs)
+	if len(accounts) == 0 {
+		return ExportFileResult{Success: false, Message: "没有选中任何账户"}
+	}
+	if password == "" && keyFilePath == "" {
+		return ExportFileResult{Success: false, Message: "请设置 KeePass 数据库密码或密钥文件"}
+	}
+
+	var keyFile []byte
+	if keyFilePath != "" {
+		var err error
+		keyFile, err = readFile(keyFilePath)
+		if err != nil {
+			return ExportFileResult{Success: false, Message: fmt.Sprintf("读取密钥文件失败: %v", err)}
+		}
+	}
+
+	data, err := migration.GenerateKeePassDatabase(accounts, password, keyFile)
+	if err != nil {
+		return ExportFileResult{Success: false, Message: fmt.Sprintf("生成 KeePass 数据库失败: %v", err)}
+	}
+
+	path, err := a.saveExportFile("导出 KeePass 数据库", "authenticator.kdbx", backupFormats["keepass"].filters, data)
+	if err != nil {
+		return ExportFileResult{Success: false, Message: err.Error()}
+	}
+	return ExportFileResult{
+		Success: true,
+		Message: fmt.Sprintf("成功导出 %d 个账户", len(accounts)),
+		Count:   len(accounts),
+		Path:    path,
+	}
+}
+
+// === Steam ===
+
+// steamManifestName Steam Desktop Authenticator 保存加密参数的文件
+const steamManifestName = "manifest.json"
+
+// openSteamMaFile 选择 .maFile，返回读取了同目录 manifest.json 的 Steam 格式及文件内容
+// .maFile 的解析依赖文件路径，因此不在 backupFormats 中注册
+func (a *App) openSteamMaFile() (backupFormat, []byte, error) {
+	f := backupFormat{
+		name: "Steam",
+		filters: []runtime.FileFilter{
+			{DisplayName: "Steam Desktop Authenticator (*.maFile)", Pattern: "*.maFile"},
+		},
+	}
+	file, err := runtime.OpenFileDialog(a.ctx, runtime.OpenDialogOptions{
+		Title:   "选择 Steam Desktop Authenticator 的 .maFile 文件",
+		Filters: f.filters,
+	})
+	if err != nil {
+		return backupFormat{}, nil, fmt.Errorf("打开文件失败: %v", err)
+	}
+	if file == "" {
+		return backupFormat{}, nil, errNoFileSelected
+	}
+
+	data, err := readFile(file)
+	if err != nil {
+		return backupFormat{}, nil, fmt.Errorf("读取文件失败: %v", err)
+	}
+
+	// 加密的 .maFile 需要同目录 manifest.json 中的盐值和 IV，未加密时可以没有
+	manifest, _ := readFile(filepath.Join(filepath.Dir(file), steamManifestName))
+	f.parse = func(data []byte, passkey string) ([]otp.Account, []string, error) {
+		return migration.ParseSteamMaFileWithManifest(data, filepath.Base(file), manifest, passkey)
+	}
+	return f, data, nil
+}
+
+// ImportFromSteamMaFile 选择 Steam Desktop Authenticator 的 .maFile 并导入，加密文件需提供 passkey
+func (a *App) ImportFromSteamMaFile(passkey string) ImportResult {
+	if a.db == nil {
+		return ImportResult{Success: false, Message: "数据库未初始化"}
+	}
+
+	f, data, err := a.openSteamMaFile()
+	if err != nil {
+		return ImportResult{Success: false, Message: err.Error()}
+	}
+	return a.importBackup(f, data, passkey)
+}
+
+// PreviewSteamMaFile 选择 .maFile 并将账户加入暂存区
+func (a *App) PreviewSteamMaFile(passkey string) ImportPreview {
+	if a.db == nil {
+		return ImportPreview{Success: false, Message: "数据库未初始化"}
+	}
+
+	f, data, err := a.openSteamMaFile()
+	if err != nil {
+		return ImportPreview{Success: false, Message: err.Error()}
+	}
+	return a.previewBackup(f, data, passkey)
+}
+
+// === Aegis 导出 ===
+
+// ExportToAegisFile 将选中账户导出为 Aegis 格式的 JSON 文件，保留周期、分组、备注等全部字段
+// exportPassword 为空时导出明文，否则使用 scrypt 口令槽位加密；启用了应用密码时需提供 currentPassword
+func (a *App) ExportToAegisFile(accountIDs []string, exportPassword, currentPassword string) ExportFileResult {
+	if a.db == nil {
+		return ExportFileResult{Success: false, Message: "数据库未初始化"}
+	}
+	if !a.checkExportPassword(currentPassword) {
+		return ExportFileResult{Success: false, Message: "密码错误"}
+	}
+
+	accounts := a.selectAccounts(accountIDs)
+	if len(accounts) == 0 {
+		return ExportFileResult{Success: false, Message: "没有选中任何账户"}
+	}
+
+	data, skipped, err := migration.GenerateAegisVault(accounts, exportPassword)
+	if err != nil {
+		return ExportFileResult{Success: false, Message: fmt.Sprintf("生成 Aegis 备份失败: %v", err)}
+	}
+	count := len(accounts) - skipped
+	if count == 0 {
+		return ExportFileResult{Success: false, Message: aegisExportMessage("没有可导出的账户", skipped)}
+	}
+
+	path, err := a.saveExportFile("导出 Aegis 备份", "aegis-export.json", backupFormats["aegis"].filters, data)
+	if err != nil {
+		return ExportFileResult{Success: false, Message: err.Error()}
+	}
+
+	message := aegisExportMessage(fmt.Sprintf("成功导出 %d 个账户", count), skipped)
+	if exportPassword == "" {
+		message += "，文件未加密，请妥善保管"
+	}
+	return ExportFileResult{Success: true, Message: message, Count: count, Path: path}
+}
+
+// aegisExportMessage 生成 Aegis 导出结果提示，说明被跳过的 OCRA 账户
+func aegisExportMessage(message string, skipped int) string {
+	if skipped == 0 {
+		return message
+	}
+	return fmt.Sprintf("%s，已跳过 %d 个账户（Aegis 不支持 OCRA 令牌）", message, skipped)
+}
+
+// checkExportPassword 导出明文密钥前验证应用密码，未启用密码时直接通过
+func (a *App) checkExportPassword(password string) bool {
+	if !a.db.HasPassword() {
+		return true
+	}
+	return a.db.VerifyPassword(password)
+}
+
+// === 文本导出 ===
+
+// ExportToCSVFile 将账户导出为 CSV（name, issuer, secret, algorithm, digits, type, period, counter, group）
+// accountIDs 为空时导出 group 分组内的全部账户；启用了应用密码时需提供 password
+func (a *App) ExportToCSVFile(accountIDs []string, group, password string) ExportFileResult {
+	return a.exportTextFile(accountIDs, group, password, "导出 CSV", "authenticator-accounts.csv",
+		[]runtime.FileFilter{{DisplayName: "CSV 文件 (*.csv)", Pattern: "*.csv"}},
+		migration.GenerateCSV)
+}
+
+// ExportToURIListFile 将账户导出为每行一个 otpauth:// URI 的文本文件
+// accountIDs 为空时导出 group 分组内的全部账户；启用了应用密码时需提供 password
+func (a *App) ExportToURIListFile(accountIDs []string, group, password string) ExportFileResult {
+	return a.exportTextFile(accountIDs, group, password, "导出 otpauth URI 列表", "authenticator-uris.txt",
+		backupFormats["otpauth"].filters,
+		func(accounts []otp.Account) ([]byte, error) {
+			return migration.GenerateURIList(accounts), nil
+		})
+}
+
+// exportTextFile 验证密码后将账户以明文格式写入所选文件
+func (a *App) exportTextFile(accountIDs []string, group, password, title, defaultName string,
+	filters []runtime.FileFilter, generate func([]otp.Account) ([]byte, error)) ExportFileResult {
+	if a.db == nil {
+		return ExportFileResult{Success: false, Message: "数据库未初始化"}
+	}
+	if !a.checkExportPassword(password) {
+		return ExportFileResult{Success: false, Message: "密码错误"}
+	}
+
+	var accounts []otp.Account
+	if len(accountIDs) > 0 {
+		accounts = a.selectAccounts(accountIDs)
+	} else if group != "" {
+		var err error
+		accounts, err = a.groupAccounts(group)
+		if err != nil {
+			return ExportFileResult{Success: false, Message: fmt.Sprintf("读取账户失败: %v", err)}
+		}
+	}
+	if len(accounts) == 0 {
+		return ExportFileResult{Success: false, Message: "没有选中任何账户"}
+	}
+
+	data, err := generate(accounts)
+	if err != nil {
+		return ExportFileResult{Success: false, Message: fmt.Sprintf("生成导出文件失败: %v", err)}
+	}
+
+	path, err := a.saveExportFile(title, defaultName, filters, data)
+	if err != nil {
+		return ExportFileResult{Success: false, Message: err.Error()}
+	}
+	return ExportFileResult{
+		Success: true,
+		Message: fmt.Sprintf("成功导出 %d 个账户，文件包含明文密钥，请妥善保管", len(accounts)),
+		Count:   len(accounts),
+		Path:    path,
+	}
+}
+
+// groupAccounts 返回指定分组内的全部账户
+func (a *App) groupAccounts(group string) ([]otp.Account, error) {
+	all, err := a.db.GetAllAccounts()
+	if err != nil {
+		return nil, err
+	}
+	var accounts []otp.Account
+	for _, acc := range all {
+		if acc.Group == group {
+			accounts = append(accounts, storageAccountToOTP(acc))
+		}
+	}
+	return accounts, nil
+}
+
+// === 便携备份 ===
+
+// parsePortableBackup 解密 .gabak 备份并返回其中的账户，用于按重复策略合并导入
+func parsePortableBackup(data []byte, password string) ([]otp.Account, []string, error) {
+	backup, err := storage.OpenBackup(data, password)
+	switch {
+	case errors.Is(err, storage.ErrBackupPasswordNeeded):
+		return nil, nil, migration.ErrPasswordRequired
+	case errors.Is(err, storage.ErrBackupPassword):
+		return nil, nil, migration.ErrWrongPassword
+	case err != nil:
+		return nil, nil, err
+	}
+
+	accounts := make([]otp.Account, 0, len(backup.Accounts))
+	for _, acc := range backup.Accounts {
+		acc.ID = "" // 合并导入时重新分配 ID，避免覆盖本机的同 ID 账户
+		accounts = append(accounts, storageAccountToOTP(acc))
+	}
+	return accounts, nil, nil
+}
+
+// ExportPortableBackup 将全部账户和设置导出为 .gabak 便携备份
+// 备份使用独立的 backupPassword 加密，可在其他设备或重装后恢复；启用了应用密码时需提供 currentPassword
+func (a *App) ExportPortableBackup(backupPassword, currentPassword string) ExportFileResult {
+	if a.db == nil {
+		return ExportFileResult{Success: false, Message: "数据库未初始化"}
+	}
+	if backupPassword == "" {
+		return ExportFileResult{Success: false, Message: "请设置备份密码"}
+	}
+	if !a.checkExportPassword(currentPassword) {
+		return ExportFileResult{Success: false, Message: "密码错误"}
+	}
+
+	accounts, err := a.db.GetAllAccounts()
+	if err != nil {
+		return ExportFileResult{Success: false, Message: fmt.Sprintf("读取账户失败: %v", err)}
+	}
+	data, err := a.db.ExportBackup(backupPassword)
+	if err != nil {
+		return ExportFileResult{Success: false, Message: fmt.Sprintf("生成备份失败: %v", err)}
+	}
+
+	defaultName := fmt.Sprintf("authenticator-%s%s", time.Now().Format("20060102"), storage.BackupFileExt)
+	path, err := a.saveExportFile("导出便携备份", defaultName, backupFormats["gabak"].filters, data)
+	if err != nil {
+		return ExportFileResult{Success: false, Message: err.Error()}
+	}
+	return ExportFileResult{
+		Success: true,
+		Message: fmt.Sprintf("成功备份 %d 个账户", len(accounts)),
+		Count:   len(accounts),
+		Path:    path,
+	}
+}
+
+// RestorePortableBackup 选择 .gabak 备份并用其内容替换全部账户和设置
+// 如需与现有账户合并，使用 ImportFromBackupFile("gabak", password)
+func (a *App) RestorePortableBackup(backupPassword string) ImportResult {
+	if a.db == nil {
+		return ImportResult{Success: false, Message: "数据库未初始化"}
+	}
+
+	data, err := a.openBackupFile(backupFormats["gabak"])
+	if err != nil {
+		return ImportResult{Success: false, Message: err.Error()}
+	}
+
+	backup, err := storage.OpenBackup(data, backupPassword)
+	switch {
+	case errors.Is(err, storage.ErrBackupPasswordNeeded):
+		return ImportResult{Success: false, Message: "请输入备份密码", NeedsPassword: true}
+	case errors.Is(err, storage.ErrBackupPassword):
+		return ImportResult{Success: false, Message: "备份密码错误或备份文件已损坏", NeedsPassword: true}
+	case err != nil:
+		return ImportResult{Success: false, Message: fmt.Sprintf("读取备份失败: %v", err)}
+	}
+
+	if err := a.db.RestoreBackup(backup); err != nil {
+		return ImportResult{Success: false, Message: fmt.Sprintf("恢复备份失败: %v", err)}
+	}
+
+	accounts := make([]otp.Account, 0, len(backup.Accounts))
+	for _, acc := range backup.Accounts {
+		accounts = append(accounts, storageAccountToOTP(acc))
+	}
+	return ImportResult{
+		Success:  true,
+		Message:  fmt.Sprintf("已从 %s 的备份恢复 %d 个账户", backup.CreatedAt.Local().Format("2006-01-02 15:04"), len(accounts)),
+		Count:    len(accounts),
+		Accounts: accounts,
+	}
+}
+
+// === 自动快照 ===
+
+// snapshotCheckInterval 检查是否需要定时快照的间隔
+const snapshotCheckInterval = 10 * time.Minute
+
+// SnapshotSettings 快照设置
+type SnapshotSettings struct {
+	Dir           string `json:"dir"`            // 快照目录，空为默认目录
+	Keep          int    `json:"keep"`           // 保留份数
+	IntervalHours int    `json:"interval_hours"` // 定时快照间隔（小时），负数表示关闭
+}
+
+// runSnapshotSchedule 定期检查并生成定时快照，数据库锁定时跳过
+func (a *App) runSnapshotSchedule(ctx context.Context) {
+	ticker := time.NewTicker(snapshotCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		if a.db != nil {
+			if _, err := a.db.SnapshotIfDue(); err != nil {
+				runtime.LogError(ctx, fmt.Sprintf("Failed to create scheduled snapshot: %v", err))
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ListSnapshots 列出本地快照，按时间从新到旧排列
+func (a *App) ListSnapshots() []storage.SnapshotInfo {
+	if a.db == nil {
+		return []storage.SnapshotInfo{}
+	}
+	snapshots, err := a.db.ListSnapshots()
+	if err != nil {
+		return []storage.SnapshotInfo{}
+	}
+	return snapshots
+}
+
+// CreateSnapshot 立即生成一份手动快照
+func (a *App) CreateSnapshot() bool {
+	if a.db == nil {
+		return false
+	}
+	_, err := a.db.Snapshot(storage.SnapshotReasonManual)
+	return err == nil
+}
+
+// RestoreSnapshot 用指定快照替换当前数据，恢复前会自动为当前状态生成快照
+// 快照启用了密码时恢复后需要重新解锁，前端应随后检查 NeedsUnlock
+func (a *App) RestoreSnapshot(name string) bool {
+	if a.db == nil {
+		return false
+	}
+	if err := a.db.RestoreSnapshot(name); err != nil {
+		runtime.LogError(a.ctx, fmt.Sprintf("Failed to restore snapshot: %v", err))
+		return false
+	}
+	return true
+}
+
+// GetSnapshotSettings 获取快照设置，零值已替换为默认值
+func (a *App) GetSnapshotSettings() SnapshotSettings {
+	result := SnapshotSettings{
+		Keep:          storage.DefaultSnapshotKeep,
+		IntervalHours: storage.DefaultSnapshotIntervalHours,
+	}
+	if a.db == nil {
+		return result
+	}
+
+	settings, _ := a.db.GetSettings()
+	result.Dir = settings.SnapshotDir
+	if settings.SnapshotKeep > 0 {
+		result.Keep = settings.SnapshotKeep
+	}
+	if settings.SnapshotIntervalHours != 0 {
+		result.IntervalHours = settings.SnapshotIntervalHours
+	}
+	return result
+}
+
+// SetSnapshotSettings 保存快照设置
+func (a *App) SetSnapshotSettings(s SnapshotSettings) bool {
+	if a.db == nil {
+		return false
+	}
+	if s.Keep < 1 {
+		s.Keep = 1
+	}
+	if s.IntervalHours < 0 {
+		s.IntervalHours = -1
+	}
+	if s.Dir != "" {
+		if err := os.MkdirAll(s.Dir, 0700); err != nil {
+			return false
+		}
+	}
+
+	settings, _ := a.db.GetSettings()
+	settings.SnapshotDir = s.Dir
+	settings.SnapshotKeep = s.Keep
+	settings.SnapshotIntervalHours = s.IntervalHours
+	return a.db.SaveSettings(settings) == nil
+}
+
+// SelectSnapshotDir 打开目录选择对话框，返回选中的快照目录
+func (a *App) SelectSnapshotDir() string {
+	dir, err := runtime.OpenDirectoryDialog(a.ctx, runtime.OpenDialogOptions{
+		Title:                "选择快照目录",
+		CanCreateDirectories: true,
+	})
+	if err != nil {
+		return ""
+	}
+	return dir
+}
+
+// === 时间校准 ===
+
+// TimeSettings 时间校准设置
+type TimeSettings struct {
+	Server       string `json:"server"`        // 时间服务器，空为默认服务器
+	OffsetMillis int64  `json:"offset_millis"` // 本机时钟偏差补偿（毫秒）
+	AutoSync     bool   `json:"auto_sync"`     // 解锁后自动同步
+}
+
+// TimeSyncResult 与时间服务器同步的结果
+type TimeSyncResult struct {
+	Success      bool   `json:"success"`
+	Server       string `json:"server"`
+	OffsetMillis int64  `json:"offset_millis"` // 服务器时间减去本机时间
+	RTTMillis    int64  `json:"rtt_millis"`
+	Message      string `json:"message"`
+}
+
+// applyTimeSettings 解锁后应用保存的时钟偏差，并按设置在后台同步
+func (a *App) applyTimeSettings() {
+	settings, err := a.db.GetSettings()
+	if err != nil {
+		return
+	}
+	otp.SetOffset(time.Duration(settings.ClockOffsetMillis) * time.Millisecond)
+
+	if settings.AutoTimeSync {
+		go func() {
+			if result := a.SyncTime(); !result.Success && a.ctx != nil {
+				runtime.LogError(a.ctx, fmt.Sprintf("Failed to sync time: %s", result.Message))
+			}
+		}()
+	}
+}
+
+// GetTimeSettings 获取时间校准设置
+func (a *App) GetTimeSettings() TimeSettings {
+	result := TimeSettings{
+		Server:       ntp.DefaultServer,
+		OffsetMillis: otp.Offset().Milliseconds(),
+	}
+	if a.db == nil {
+		return result
+	}
+
+	settings, _ := a.db.GetSettings()
+	if settings.NTPServer != "" {
+		result.Server = settings.NTPServer
+	}
+	result.AutoSync = settings.AutoTimeSync
+	return result
+}
+
+// SetTimeSettings 保存时间校准设置，OffsetMillis 为手动设置的偏差，立即生效
+func (a *App) SetTimeSettings(s TimeSettings) bool {
+	if a.db == nil {
+		return false
+	}
+	if d := time.Duration(s.OffsetMillis) * time.Millisecond; d > ntp.MaxOffset || d < -ntp.MaxOffset {
+		return false
+	}
+
+	settings, _ := a.db.GetSettings()
+	settings.NTPServer = strings.TrimSpace(s.Server)
+	if settings.NTPServer == ntp.DefaultServer {
+		settings.NTPServer = ""
+	}
+	settings.ClockOffsetMillis = s.OffsetMillis
+	settings.AutoTimeSync = s.AutoSync
+	if err := a.db.SaveSettings(settings); err != nil {
+		return false
+	}
+	otp.SetOffset(time.Duration(s.OffsetMillis) * time.Millisecond)
+	return true
+}
+
+// SyncTime 向设置中的时间服务器测量本机时钟偏差，并应用到所有验证码
+func (a *App) SyncTime() TimeSyncResult {
+	if a.db == nil {
+		return TimeSyncResult{Success: false, Message: "数据库未初始化"}
+	}
+
+	settings, err := a.db.GetSettings()
+	if err != nil {
+		return TimeSyncResult{Success: false, Message: fmt.Sprintf("读取设置失败: %v", err)}
+	}
+
+	result, err := ntp.Query(settings.NTPServer, ntp.DefaultTimeout)
+	if err != nil {
+		return TimeSyncResult{Success: false, Message: fmt.Sprintf("同步失败: %v", err)}
+	}
+
+	offset := result.Offset.Round(time.Millisecond)
+	otp.SetOffset(offset)
+	settings.ClockOffsetMillis = offset.Milliseconds()
+	if err := a.db.SaveSettings(settings); err != nil {
+		return TimeSyncResult{Success: false, Message: fmt.Sprintf("保存设置失败: %v", err)}
+	}
+
+	return TimeSyncResult{
+		Success:      true,
+		Server:       result.Server,
+		OffsetMillis: offset.Milliseconds(),
+		RTTMillis:    result.RTT.Milliseconds(),
+		Message:      fmt.Sprintf("本机时钟偏差 %d 毫秒", offset.Milliseconds()),
+	}
+}
+
+// selectAccounts 按 accountIDs 的顺序收集待导出的账户
+func (a *App) selectAccounts(accountIDs []string) []otp.Account {
+	all, _ := a.db.GetAllAccounts()
+	byID := make(map[string]storage.Account, len(all))
+	for _, acc := range all {
+		byID[acc.ID] = acc
+	}
+
+	var accounts []otp.Account
+	for _, id := range accountIDs {
+		if acc, ok := byID[id]; ok {
+			accounts = append(accounts, storageAccountToOTP(acc))
+		}
+	}
+	return accounts
+}
+
+// saveExportFile 打开保存对话框并写入导出文件，返回保存路径
+func (a *App) saveExportFile(title, defaultName string, filters []runtime.FileFilter, data []byte) (string, error) {
+	path, err := runtime.SaveFileDialog(a.ctx, runtime.SaveDialogOptions{
+		Title:           title,
+		DefaultFilename: defaultName,
+		Filters:         filters,
+	})
+	if err != nil {
+		return "", fmt.Errorf("打开保存对话框失败: %v", err)
+	}
+	if path == "" {
+		return "", errNoFileSelected
+	}
+
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return "", fmt.Errorf("写入文件失败: %v", err)
+	}
+	return path, nil
+}
+
+// readFile 读取文件内容
+func readFile(path string) ([]byte, error) {
+	return os.ReadFile(path)
+}
+
+// AddAccount adds a new account manually
+func (a *App) AddAccount(name, issuer, secret, algorithm, otpType string, digits, period int) ImportResult {
+	return a.AddAccountWithGroup(name, issuer, secret, algorithm, otpType, digits, period, "")
+}
+
+// AddAccountWithGroup adds a new account with group
+func (a *App) AddAccountWithGroup(name, issuer, secret, algorithm, otpType string, digits, period int, group string) ImportResult {
+	if a.db == nil {
+		return ImportResult{Success: false, Message: "数据库未初始化"}
+	}
+
+	acc := otp.Account{
+		ID:        uuid.New().String(),
+		Name:      name,
+		Issuer:    issuer,
+		Secret:    secret,
+		Algorithm: algorithm,
+		Digits:    digits,
+		Type:      otpType,
+		Period:    period,
+		Counter:   0,
+		Group:     group,
+	}
+
+	// 保存到数据库
+	if err := a.db.SaveAccount(otpAccountToStorage(acc)); err != nil {
+		return ImportResult{
+			Success: false,
+			Message: fmt.Sprintf("保存失败: %v", err),
+		}
+	}
+
+	return ImportResult{
+		Success:  true,
+		Message:  "账户添加成功",
+		Count:    1,
+		Accounts: []otp.Account{acc},
+	}
+}
+
+// AddPinAccount 手动添加需要 PIN 的 Yandex Key 或 mOTP 账户
+// Yandex Key 的密钥为 base32，mOTP 的密钥为十六进制
+func (a *App) AddPinAccount(name, issuer, secret, pin, otpType, group string) ImportResult {
+	if a.db == nil {
+		return ImportResult{Success: false, Message: "数据库未初始化"}
+	}
+	if pin == "" {
+		return ImportResult{Success: false, Message: "请输入 PIN"}
+	}
+
+	acc := otp.Account{
+		ID:     uuid.New().String(),
+		Name:   name,
+		Issuer: issuer,
+		Type:   strings.ToUpper(otpType),
+		Pin:    pin,
+		Group:  group,
+	}
+	switch acc.Type {
+	case otp.TypeYandex:
+		decoded, err := otp.DecodeSecret(secret)
+		if err != nil || len(decoded) == 0 {
+			return ImportResult{Success: false, Message: "密钥无效"}
+		}
+		acc.Secret = base32.StdEncoding.EncodeToString(decoded)
+		acc.Algorithm, acc.Digits, acc.Period = "SHA256", otp.YandexDigits, 30
+	case otp.TypeMOTP:
+		converted, err := otp.MOTPSecretFromHex(secret)
+		if err != nil {
+			return ImportResult{Success: false, Message: fmt.Sprintf("密钥无效: %v", err)}
+		}
+		acc.Secret = converted
+		acc.Algorithm, acc.Digits, acc.Period = "MD5", otp.MOTPDigits, otp.MOTPPeriod
+	default:
+		return ImportResult{Success: false, Message: fmt.Sprintf("不支持的类型: %s", otpType)}
+	}
+
+	if err := a.db.SaveAccount(otpAccountToStorage(acc)); err != nil {
+		return ImportResult{
+			Success: false,
+			Message: fmt.Sprintf("保存失败: %v", err),
+		}
+	}
+
+	return ImportResult{
+		Success:  true,
+		Message:  "账户添加成功",
+		Count:    1,
+		Accounts: []otp.Account{acc},
+	}
+}
+
+// AddOCRAAccount 手动添加 OCRA 账户，suite 如 OCRA-1:HOTP-SHA1-6:QN08，套件包含 P 时需要 PIN
+func (a *App) AddOCRAAccount(name, issuer, secret, suite, pin, group string) ImportResult {
+	if a.db == nil {
+		return ImportResult{Success: false, Message: "数据库未初始化"}
+	}
+
+	parsed, err := otp.ParseOCRASuite(suite)
+	if err != nil {
+		return ImportResult{Success: false, Message: fmt.Sprintf("OCRA 套件无效: %v", err)}
+	}
+	if parsed.PinHash != "" && pin == "" {
+		return ImportResult{Success: false, Message: "该套件需要 PIN"}
+	}
+	decoded, err := otp.DecodeSecret(secret)
+	if err != nil || len(decoded) == 0 {
+		return ImportResult{Success: false, Message: "密钥无效"}
+	}
+
+	acc := otp.Account{
+		ID:        uuid.New().String(),
+		Name:      name,
+		Issuer:    issuer,
+		Secret:    base32.StdEncoding.EncodeToString(decoded),
+		Algorithm: parsed.Algorithm,
+		Digits:    parsed.Digits,
+		Type:      otp.TypeOCRA,
+		Pin:       pin,
+		OcraSuite: parsed.Suite,
+		Group:     group,
+	}
+
+	if err := a.db.SaveAccount(otpAccountToStorage(acc)); err != nil {
+		return ImportResult{
+			Success: false,
+			Message: fmt.Sprintf("保存失败: %v", err),
+		}
+	}
+
+	return ImportResult{
+		Success:  true,
+		Message:  "账户添加成功",
+		Count:    1,
+		Accounts: []otp.Account{acc},
 	}
 }
 
@@ -569,12 +2360,7 @@ func (a *App) DeleteAccounts(accountIDs []string) int {
 		return 0
 	}
 
-	count := 0
-	for _, id := range accountIDs {
-		if a.db.DeleteAccount(id) == nil {
-			count++
-		}
-	}
+	count, _ := a.db.DeleteAccounts(accountIDs)
 	return count
 }
 
@@ -607,17 +2393,7 @@ func (a *App) UpdateAccountsGroup(accountIDs []string, group string) int {
 		return 0
 	}
 
-	count := 0
-	for _, id := range accountIDs {
-		acc, err := a.db.GetAccount(id)
-		if err != nil || acc == nil {
-			continue
-		}
-		acc.Group = group
-		if a.db.SaveAccount(*acc) == nil {
-			count++
-		}
-	}
+	count, _ := a.db.UpdateAccountsGroup(accountIDs, group)
 	return count
 }
 
@@ -717,6 +2493,97 @@ func (a *App) GenerateCode(accountID string) GenerateCodeResult {
 		}
 	}
 
+	return generateAccountCode(*acc)
+}
+
+// ComputeOCRAResponse 使用 OCRA 账户计算服务端挑战的应答
+// 套件包含计数器时每次计算后计数器加一，不支持需要会话信息的套件
+func (a *App) ComputeOCRAResponse(accountID, challenge string) OCRAResponseResult {
+	if a.db == nil {
+		return OCRAResponseResult{Success: false, Message: "数据库未初始化"}
+	}
+
+	acc, err := a.db.GetAccount(accountID)
+	if err != nil || acc == nil {
+		return OCRAResponseResult{Success: false, Message: "账户不存在"}
+	}
+	if strings.ToUpper(acc.Type) != otp.TypeOCRA {
+		return OCRAResponseResult{Success: false, Message: "该账户不是 OCRA 令牌"}
+	}
+
+	suite, err := otp.ParseOCRASuite(acc.OcraSuite)
+	if err != nil {
+		return OCRAResponseResult{Success: false, Message: fmt.Sprintf("OCRA 套件无效: %v", err)}
+	}
+	if suite.SessionLen > 0 {
+		return OCRAResponseResult{Success: false, Message: "不支持需要会话信息的 OCRA 套件"}
+	}
+
+	response, err := suite.Compute(acc.Secret, otp.OCRAInput{
+		Counter:   acc.Counter,
+		Challenge: strings.TrimSpace(challenge),
+		Pin:       acc.Pin,
+		Time:      otp.Now(),
+	})
+	if err != nil {
+		return OCRAResponseResult{Success: false, Message: fmt.Sprintf("计算失败: %v", err)}
+	}
+
+	if suite.Counter {
+		acc.Counter++
+		if err := a.db.SaveAccount(*acc); err != nil {
+			return OCRAResponseResult{Success: false, Message: fmt.Sprintf("保存计数器失败: %v", err)}
+		}
+	}
+
+	return OCRAResponseResult{Success: true, Response: response}
+}
+
+// generateAccountCode 按账户类型生成验证码
+func generateAccountCode(acc storage.Account) GenerateCodeResult {
+	// Steam Guard 固定 30 秒周期
+	if strings.ToUpper(acc.Type) == otp.TypeSteam {
+		code, remaining, err := otp.GenerateSteam(acc.Secret)
+		if err != nil {
+			return GenerateCodeResult{Code: "ERROR"}
+		}
+		return GenerateCodeResult{
+			Code:      code,
+			Remaining: remaining,
+			Progress:  otp.GetProgress(30),
+		}
+	}
+
+	// Yandex Key 和 mOTP 需要 PIN，OCRA 需要挑战，通过 ComputeOCRAResponse 计算
+	switch strings.ToUpper(acc.Type) {
+	case otp.TypeOCRA:
+		return GenerateCodeResult{Code: "------"}
+	case otp.TypeYandex:
+		period := acc.Period
+		if period == 0 {
+			period = 30
+		}
+		code, remaining, err := otp.GenerateYandex(acc.Secret, acc.Pin, period)
+		if err != nil {
+			return GenerateCodeResult{Code: "ERROR"}
+		}
+		return GenerateCodeResult{
+			Code:      code,
+			Remaining: remaining,
+			Progress:  otp.GetProgress(period),
+		}
+	case otp.TypeMOTP:
+		code, remaining, err := otp.GenerateMOTP(acc.Secret, acc.Pin)
+		if err != nil {
+			return GenerateCodeResult{Code: "ERROR"}
+		}
+		return GenerateCodeResult{
+			Code:      code,
+			Remaining: remaining,
+			Progress:  otp.GetProgress(otp.MOTPPeriod),
+		}
+	}
+
 	// Generate code
 	if strings.ToUpper(acc.Type) == "HOTP" {
 		code, err := otp.GenerateHOTP(acc.Secret, acc.Algorithm, acc.Digits, acc.Counter)
@@ -749,68 +2616,123 @@ func (a *App) GenerateCode(accountID string) GenerateCodeResult {
 	}
 }
 
-// ExportToMigrationQR exports selected accounts to QR code
-func (a *App) ExportToMigrationQR(accountIDs []string, size int) ExportQRResult {
-	if a.db == nil {
-		return ExportQRResult{Success: false, Message: "数据库未初始化"}
+// errNotMigratable Google 迁移协议没有 Steam、Yandex Key、mOTP 和 OCRA 令牌类型，
+// 也只能表示 6/8 位、30 秒周期的验证码，导出其他账户后验证码会不正确
+var errNotMigratable = errors.New("Google 迁移格式不支持")
+
+// storageAccountToMigrationParam 将 storage.Account 转换为迁移参数
+// 无法导出时返回的错误说明具体原因
+func storageAccountToMigrationParam(acc storage.Account) (*migration.OtpParameters, error) {
+	switch strings.ToUpper(acc.Type) {
+	case otp.TypeSteam:
+		return nil, fmt.Errorf("Steam: %w", errNotMigratable)
+	case otp.TypeYandex:
+		return nil, fmt.Errorf("Yandex Key: %w", errNotMigratable)
+	case otp.TypeMOTP:
+		return nil, fmt.Errorf("mOTP: %w", errNotMigratable)
+	case otp.TypeOCRA:
+		return nil, fmt.Errorf("OCRA: %w", errNotMigratable)
+	}
+	if acc.Digits != 0 && acc.Digits != 6 && acc.Digits != 8 {
+		return nil, fmt.Errorf("%d 位验证码: %w（仅支持 6 或 8 位）", acc.Digits, errNotMigratable)
+	}
+	if strings.ToUpper(acc.Type) != "HOTP" && acc.Period != 0 && acc.Period != 30 {
+		return nil, fmt.Errorf("%d 秒周期: %w（仅支持 30 秒）", acc.Period, errNotMigratable)
+	}
+
+	// Decode secret
+	secret, err := otp.DecodeSecret(acc.Secret)
+	if err != nil {
+		return nil, fmt.Errorf("密钥无效: %w", err)
+	}
+
+	param := &migration.OtpParameters{
+		Secret: secret,
+		Name:   acc.Name,
+		Issuer: acc.Issuer,
+	}
+
+	// Set algorithm
+	switch strings.ToUpper(acc.Algorithm) {
+	case "SHA256":
+		param.Algorithm = migration.AlgorithmSHA256
+	case "SHA512":
+		param.Algorithm = migration.AlgorithmSHA512
+	case "MD5":
+		param.Algorithm = migration.AlgorithmMD5
+	default:
+		param.Algorithm = migration.AlgorithmSHA1
+	}
+
+	// Set digits
+	if acc.Digits == 8 {
+		param.Digits = migration.DigitCountEight
+	} else {
+		param.Digits = migration.DigitCountSix
 	}
 
+	// Set type
+	if strings.ToUpper(acc.Type) == "HOTP" {
+		param.Type = migration.OtpTypeHOTP
+		param.Counter = acc.Counter
+	} else {
+		param.Type = migration.OtpTypeTOTP
+	}
+
+	return param, nil
+}
+
+// selectMigrationParams 按 accountIDs 的顺序收集待导出的迁移参数
+// 无法用迁移协议表示的账户（Steam、Yandex Key、mOTP、OCRA 令牌及非 6/8 位、非 30 秒周期的令牌）
+// 和密钥无法解码的账户被跳过，skipped 按“账户名: 原因”列出每个被跳过的账户
+func (a *App) selectMigrationParams(accountIDs []string) (selectedAccounts []*migration.OtpParameters, skipped []string) {
 	accounts, _ := a.db.GetAllAccounts()
 
 	// Find accounts by IDs
-	var selectedAccounts []*migration.OtpParameters
 	for _, id := range accountIDs {
 		for _, acc := range accounts {
 			if acc.ID == id {
-				// Decode secret
-				secret, err := base32.StdEncoding.DecodeString(acc.Secret)
+				param, err := storageAccountToMigrationParam(acc)
 				if err != nil {
-					continue
-				}
-
-				param := &migration.OtpParameters{
-					Secret: secret,
-					Name:   acc.Name,
-					Issuer: acc.Issuer,
+					skipped = append(skipped, fmt.Sprintf("%s: %v", migrationSkipLabel(acc), err))
+					break
 				}
-
-				// Set algorithm
-				switch strings.ToUpper(acc.Algorithm) {
-				case "SHA256":
-					param.Algorithm = migration.AlgorithmSHA256
-				case "SHA512":
-					param.Algorithm = migration.AlgorithmSHA512
-				case "MD5":
-					param.Algorithm = migration.AlgorithmMD5
-				default:
-					param.Algorithm = migration.AlgorithmSHA1
-				}
-
-				// Set digits
-				if acc.Digits == 8 {
-					param.Digits = migration.DigitCountEight
-				} else {
-					param.Digits = migration.DigitCountSix
-				}
-
-				// Set type
-				if strings.ToUpper(acc.Type) == "HOTP" {
-					param.Type = migration.OtpTypeHOTP
-					param.Counter = acc.Counter
-				} else {
-					param.Type = migration.OtpTypeTOTP
-				}
-
 				selectedAccounts = append(selectedAccounts, param)
 				break
 			}
 		}
 	}
+	return selectedAccounts, skipped
+}
+
+// migrationSkipLabel 返回提示中用于指代账户的名称，有发行方时写作“发行方 (账户名)”
+func migrationSkipLabel(acc storage.Account) string {
+	if acc.Issuer != "" && acc.Issuer != acc.Name {
+		return fmt.Sprintf("%s (%s)", acc.Issuer, acc.Name)
+	}
+	return acc.Name
+}
+
+// migrationExportMessage 生成迁移码导出结果提示，逐个列出被跳过的账户及原因
+func migrationExportMessage(message string, skipped []string) string {
+	if len(skipped) == 0 {
+		return message
+	}
+	return fmt.Sprintf("%s，已跳过 %d 个无法导出的账户：%s", message, len(skipped), strings.Join(skipped, "；"))
+}
+
+// ExportToMigrationQR exports selected accounts to QR code
+func (a *App) ExportToMigrationQR(accountIDs []string, size int) ExportQRResult {
+	if a.db == nil {
+		return ExportQRResult{Success: false, Message: "数据库未初始化"}
+	}
 
+	selectedAccounts, skipped := a.selectMigrationParams(accountIDs)
 	if len(selectedAccounts) == 0 {
 		return ExportQRResult{
 			Success: false,
-			Message: "没有选中任何账户",
+			Message: migrationExportMessage("没有可导出的账户", skipped),
+			Skipped: skipped,
 		}
 	}
 
@@ -837,7 +2759,73 @@ func (a *App) ExportToMigrationQR(accountIDs []string, size int) ExportQRResult
 
 	return ExportQRResult{
 		Success:   true,
-		Message:   fmt.Sprintf("成功导出 %d 个账户", len(selectedAccounts)),
+		Message:   migrationExportMessage(fmt.Sprintf("成功导出 %d 个账户", len(selectedAccounts)), skipped),
 		QRCodeURL: qrDataURL,
+		Skipped:   skipped,
+	}
+}
+
+// ExportToMigrationQRBatches exports selected accounts to multiple migration QR codes
+// perCode 为每个二维码的账户上限（0 使用默认值 10），level 为纠错等级 L/M/Q/H（空值使用 M）
+func (a *App) ExportToMigrationQRBatches(accountIDs []string, size, perCode int, level string) ExportQRBatchResult {
+	if a.db == nil {
+		return ExportQRBatchResult{Success: false, Message: "数据库未初始化"}
+	}
+
+	selectedAccounts, skipped := a.selectMigrationParams(accountIDs)
+	if len(selectedAccounts) == 0 {
+		return ExportQRBatchResult{
+			Success: false,
+			Message: migrationExportMessage("没有可导出的账户", skipped),
+			Skipped: skipped,
+		}
+	}
+
+	if perCode <= 0 {
+		perCode = migration.DefaultAccountsPerBatch
+	}
+	if level == "" {
+		level = qrcode.LevelMedium
+	}
+	if size == 0 {
+		size = 512
+	}
+
+	uris, err := migration.GenerateMigrationURIs(selectedAccounts, perCode)
+	if err != nil {
+		return ExportQRBatchResult{
+			Success: false,
+			Message: fmt.Sprintf("生成URI失败: %v", err),
+		}
+	}
+
+	pages := make([]ExportQRPage, 0, len(uris))
+	for i, uri := range uris {
+		qrDataURL, err := qrcode.GenerateQRCodeBase64WithLevel(uri, size, level)
+		if err != nil {
+			return ExportQRBatchResult{
+				Success: false,
+				Message: fmt.Sprintf("生成第 %d 页QR码失败，请减少每页账户数或降低纠错等级: %v", i+1, err),
+			}
+		}
+
+		count := perCode
+		if remain := len(selectedAccounts) - i*perCode; remain < count {
+			count = remain
+		}
+		pages = append(pages, ExportQRPage{
+			Index:     i + 1,
+			Total:     len(uris),
+			Count:     count,
+			QRCodeURL: qrDataURL,
+		})
+	}
+
+	return ExportQRBatchResult{
+		Success: true,
+		Message: migrationExportMessage(fmt.Sprintf("成功导出 %d 个账户，共 %d 个二维码", len(selectedAccounts), len(pages)), skipped),
+		Count:   len(selectedAccounts),
+		QRCodes: pages,
+		Skipped: skipped,
 	}
 }
@@ -0,0 +1,59 @@
+package keepass
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// RFC 9106 第 5 节测试向量：password 32 字节 0x01，salt 16 字节 0x02，
+// secret 8 字节 0x03，associated data 12 字节 0x04，t=3，m=32 KiB，p=4，输出 32 字节
+func TestArgon2KeyRFC9106(t *testing.T) {
+	password := bytes.Repeat([]byte{0x01}, 32)
+	salt := bytes.Repeat([]byte{0x02}, 16)
+	secret := bytes.Repeat([]byte{0x03}, 8)
+	data := bytes.Repeat([]byte{0x04}, 12)
+
+	tests := []struct {
+		name string
+		mode int
+		want string
+	}{
+		{"Argon2d", argon2d, "512b391b6f1162975371d30919734294f868e3be3984f3c1a13a4db9fabe4acb"},
+		{"Argon2i", argon2i, "c814d9d1dc7f37aa13f0d77f2494bda1c8de6b016dd388d29952a4c4672b6ce8"},
+		{"Argon2id", argon2id, "0d640df58d78766c08c037a34a8b53c9d01ef0452d75b65eb52520e96b01e659"},
+	}
+	for _, tt := range tests {
+		got := argon2Key(tt.mode, password, salt, secret, data, 3, 32, 4, 32)
+		if hex.EncodeToString(got) != tt.want {
+			t.Errorf("%s = %x, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+// 不带 secret 与 associated data 时应与 x/crypto 的实现一致
+func TestArgon2KeyMatchesXCrypto(t *testing.T) {
+	password, salt := []byte("password"), []byte("somesalt")
+
+	got := argon2Key(argon2id, password, salt, nil, nil, 2, 64, 2, 32)
+	want := argon2.IDKey(password, salt, 2, 64, 2, 32)
+	if !bytes.Equal(got, want) {
+		t.Errorf("argon2id = %x, want %x", got, want)
+	}
+
+	got = argon2Key(argon2i, password, salt, nil, nil, 2, 64, 2, 32)
+	want = argon2.Key(password, salt, 2, 64, 2, 32)
+	if !bytes.Equal(got, want) {
+		t.Errorf("argon2i = %x, want %x", got, want)
+	}
+}
+
+func TestTransformKeyRejectsLargeIterations(t *testing.T) {
+	params := newArgon2idParams(bytes.Repeat([]byte{0x02}, 32))
+	params.setUint64("I", maxArgonIterations+1)
+	if _, err := transformKey(params, make([]byte, 32)); err == nil {
+		t.Fatal("expected error for Argon2 iterations above the limit")
+	}
+}
@@ -0,0 +1,288 @@
+package keepass
+
+import (
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/xml"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// generator 写入数据库 Meta 的生成程序名称
+const generator = "Google Authenticator Desktop"
+
+// kdbxEpochOffset 0001-01-01 到 Unix 纪元的秒数，KDBX 4 时间戳以前者为起点
+const kdbxEpochOffset = 62135596800
+
+// Database KDBX 数据库内容
+type Database struct {
+	Name           string
+	Root           *Group
+	RecycleBinUUID string // 回收站分组的 UUID（base64），未启用回收站时为空
+}
+
+// Group 分组
+type Group struct {
+	UUID    string // base64
+	Name    string
+	Notes   string
+	Entries []*Entry
+	Groups  []*Group
+}
+
+// Entry 条目，不含历史记录
+type Entry struct {
+	UUID   string // base64
+	Tags   string
+	Fields []Field // Title、UserName、Password、URL、Notes 及自定义字段，保持原顺序
+}
+
+// Field 条目的字符串字段
+type Field struct {
+	Key       string
+	Value     string
+	Protected bool // 在文件中以内层流加密保存
+}
+
+// NewDatabase 创建只含根分组的空数据库
+func NewDatabase(name string) *Database {
+	return &Database{Name: name, Root: NewGroup(name)}
+}
+
+// NewGroup 创建分组
+func NewGroup(name string) *Group {
+	return &Group{UUID: newUUID(), Name: name}
+}
+
+// NewEntry 创建空条目
+func NewEntry() *Entry {
+	return &Entry{UUID: newUUID()}
+}
+
+// Get 返回字段值，不存在时为空
+func (e *Entry) Get(key string) string {
+	for _, f := range e.Fields {
+		if f.Key == key {
+			return f.Value
+		}
+	}
+	return ""
+}
+
+// Set 设置字段值，不存在时追加
+func (e *Entry) Set(key, value string, protected bool) {
+	for i, f := range e.Fields {
+		if f.Key == key {
+			e.Fields[i] = Field{Key: key, Value: value, Protected: protected}
+			return
+		}
+	}
+	e.Fields = append(e.Fields, Field{Key: key, Value: value, Protected: protected})
+}
+
+// newUUID 生成随机 UUID（base64）
+func newUUID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return base64.StdEncoding.EncodeToString(b)
+}
+
+// node 通用 XML 节点，按文档顺序保存子节点，便于处理受保护值
+type node struct {
+	XMLName xml.Name
+	Attrs   []xml.Attr `xml:",any,attr"`
+	Text    string     `xml:",chardata"`
+	Nodes   []*node    `xml:",any"`
+}
+
+func newNode(name, text string, children ...*node) *node {
+	return &node{XMLName: xml.Name{Local: name}, Text: text, Nodes: children}
+}
+
+func (n *node) child(name string) *node {
+	for _, c := range n.Nodes {
+		if c.XMLName.Local == name {
+			return c
+		}
+	}
+	return nil
+}
+
+func (n *node) childText(name string) string {
+	if c := n.child(name); c != nil {
+		return c.Text
+	}
+	return ""
+}
+
+func (n *node) attr(name string) string {
+	for _, a := range n.Attrs {
+		if a.Name.Local == name {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+func (n *node) isProtected() bool {
+	return n.XMLName.Local == "Value" && strings.EqualFold(n.attr("Protected"), "True")
+}
+
+// processProtected 按文档顺序处理受保护值：读取时解密，写入时加密
+// 内层流是连续的密钥流，必须与 KeePass 的遍历顺序完全一致
+func processProtected(n *node, stream cipher.Stream, encrypt bool) error {
+	if n.isProtected() {
+		if encrypt {
+			buf := []byte(n.Text)
+			stream.XORKeyStream(buf, buf)
+			n.Text = base64.StdEncoding.EncodeToString(buf)
+		} else {
+			buf, err := base64.StdEncoding.DecodeString(strings.TrimSpace(n.Text))
+			if err != nil {
+				return fmt.Errorf("invalid protected value: %w", err)
+			}
+			stream.XORKeyStream(buf, buf)
+			n.Text = string(buf)
+		}
+	}
+	for _, c := range n.Nodes {
+		if err := processProtected(c, stream, encrypt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseDocument 将解密后的 XML 文档转换为 Database
+func parseDocument(doc *node) (*Database, error) {
+	if doc.XMLName.Local != "KeePassFile" {
+		return nil, fmt.Errorf("invalid database document")
+	}
+	root := doc.child("Root")
+	if root == nil || root.child("Group") == nil {
+		return nil, fmt.Errorf("database has no root group")
+	}
+
+	db := &Database{Root: parseGroup(root.child("Group"))}
+	if meta := doc.child("Meta"); meta != nil {
+		db.Name = meta.childText("DatabaseName")
+		if strings.EqualFold(strings.TrimSpace(meta.childText("RecycleBinEnabled")), "True") {
+			db.RecycleBinUUID = strings.TrimSpace(meta.childText("RecycleBinUUID"))
+		}
+	}
+	return db, nil
+}
+
+func parseGroup(n *node) *Group {
+	g := &Group{
+		UUID:  strings.TrimSpace(n.childText("UUID")),
+		Name:  n.childText("Name"),
+		Notes: n.childText("Notes"),
+	}
+	for _, c := range n.Nodes {
+		switch c.XMLName.Local {
+		case "Entry":
+			g.Entries = append(g.Entries, parseEntry(c))
+		case "Group":
+			g.Groups = append(g.Groups, parseGroup(c))
+		}
+	}
+	return g
+}
+
+func parseEntry(n *node) *Entry {
+	e := &Entry{
+		UUID: strings.TrimSpace(n.childText("UUID")),
+		Tags: n.childText("Tags"),
+	}
+	for _, c := range n.Nodes {
+		if c.XMLName.Local != "String" {
+			continue
+		}
+		f := Field{Key: c.childText("Key")}
+		if v := c.child("Value"); v != nil {
+			f.Value = v.Text
+			f.Protected = v.isProtected()
+		}
+		e.Fields = append(e.Fields, f)
+	}
+	return e
+}
+
+// document 生成待写入的 XML 文档
+func (db *Database) document(now time.Time) *node {
+	ts := formatTime(now)
+	meta := newNode("Meta", "",
+		newNode("Generator", generator),
+		newNode("DatabaseName", db.Name),
+		newNode("DatabaseNameChanged", ts),
+		newNode("MemoryProtection", "",
+			newNode("ProtectTitle", "False"),
+			newNode("ProtectUserName", "False"),
+			newNode("ProtectPassword", "True"),
+			newNode("ProtectURL", "False"),
+			newNode("ProtectNotes", "False"),
+		),
+		newNode("RecycleBinEnabled", "False"),
+	)
+	return newNode("KeePassFile", "",
+		meta,
+		newNode("Root", "", db.Root.node(ts), newNode("DeletedObjects", "")),
+	)
+}
+
+func (g *Group) node(ts string) *node {
+	n := newNode("Group", "",
+		newNode("UUID", g.UUID),
+		newNode("Name", g.Name),
+		newNode("Notes", g.Notes),
+		newNode("IconID", "48"),
+		timesNode(ts),
+		newNode("IsExpanded", "True"),
+	)
+	for _, e := range g.Entries {
+		n.Nodes = append(n.Nodes, e.node(ts))
+	}
+	for _, sub := range g.Groups {
+		n.Nodes = append(n.Nodes, sub.node(ts))
+	}
+	return n
+}
+
+func (e *Entry) node(ts string) *node {
+	n := newNode("Entry", "",
+		newNode("UUID", e.UUID),
+		newNode("IconID", "0"),
+		newNode("Tags", e.Tags),
+		timesNode(ts),
+	)
+	for _, f := range e.Fields {
+		value := newNode("Value", f.Value)
+		if f.Protected {
+			value.Attrs = []xml.Attr{{Name: xml.Name{Local: "Protected"}, Value: "True"}}
+		}
+		n.Nodes = append(n.Nodes, newNode("String", "", newNode("Key", f.Key), value))
+	}
+	return n
+}
+
+func timesNode(ts string) *node {
+	return newNode("Times", "",
+		newNode("CreationTime", ts),
+		newNode("LastModificationTime", ts),
+		newNode("LastAccessTime", ts),
+		newNode("ExpiryTime", ts),
+		newNode("Expires", "False"),
+		newNode("UsageCount", "0"),
+		newNode("LocationChanged", ts),
+	)
+}
+
+// formatTime KDBX 4 时间戳：自 0001-01-01 起的秒数（int64 小端）的 base64
+func formatTime(t time.Time) string {
+	b := binary.LittleEndian.AppendUint64(nil, uint64(t.Unix()+kdbxEpochOffset))
+	return base64.StdEncoding.EncodeToString(b)
+}
@@ -0,0 +1,487 @@
+// Package keepass 读写 KeePass KDBX 4 数据库
+//
+// 文件结构：外层头部（含 KDF 参数）→ 头部 SHA-256 与 HMAC → HMAC 分块的加密负载。
+// 负载解密、解压后依次为内层头部（内层流密钥）与 XML 文档，
+// 文档中标记为 Protected 的值再以 ChaCha20 内层流按文档顺序加密。
+package keepass
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"time"
+
+	"golang.org/x/crypto/chacha20"
+)
+
+const (
+	signature1 uint32 = 0x9aa2d903
+	signature2 uint32 = 0xb54bfb67
+
+	fileVersion4      uint32 = 0x00040000
+	fileVersionMajor4        = 4
+
+	// 写入时每个 HMAC 分块的大小
+	blockSize = 1024 * 1024
+
+	// 解压后负载的大小上限，防止压缩炸弹耗尽内存
+	maxDecompressedSize = 64 << 20
+)
+
+// 外层头部字段
+const (
+	headerEnd           = 0
+	headerCipherID      = 2
+	headerCompression   = 3
+	headerMasterSeed    = 4
+	headerEncryptionIV  = 7
+	headerKdfParameters = 11
+)
+
+// 内层头部字段
+const (
+	innerHeaderEnd       = 0
+	innerHeaderStreamID  = 1
+	innerHeaderStreamKey = 2
+)
+
+// innerStreamChaCha20 内层流算法 ChaCha20
+const innerStreamChaCha20 = 3
+
+// 负载加密算法标识
+var (
+	cipherAES256   = []byte{0x31, 0xc1, 0xf2, 0xe6, 0xbf, 0x71, 0x43, 0x50, 0xbe, 0x58, 0x05, 0x21, 0x6a, 0xfc, 0x5a, 0xff}
+	cipherChaCha20 = []byte{0xd6, 0x03, 0x8a, 0x2b, 0x8b, 0x6f, 0x4c, 0xb5, 0xa5, 0x24, 0x33, 0x9a, 0x31, 0xdb, 0xb5, 0x9a}
+	cipherTwofish  = []byte{0xad, 0x68, 0xf2, 0x9f, 0x57, 0x6f, 0x4b, 0xb9, 0xa3, 0x6a, 0xd4, 0x7a, 0xf9, 0x65, 0x34, 0x6c}
+)
+
+var (
+	ErrInvalidFile        = errors.New("not a KeePass database")
+	ErrInvalidCredentials = errors.New("wrong password or key file")
+)
+
+// outerHeader 外层头部中用到的字段
+type outerHeader struct {
+	cipherID   []byte
+	compressed bool
+	masterSeed []byte
+	iv         []byte
+	kdf        *variantDict
+}
+
+// Read 解密并解析 KDBX 4 数据库
+func Read(data []byte, creds Credentials) (*Database, error) {
+	r := bytes.NewReader(data)
+
+	var sig [3]uint32
+	if err := binary.Read(r, binary.LittleEndian, &sig); err != nil {
+		return nil, ErrInvalidFile
+	}
+	if sig[0] != signature1 || sig[1] != signature2 {
+		return nil, ErrInvalidFile
+	}
+	if major := sig[2] >> 16; major != fileVersionMajor4 {
+		return nil, fmt.Errorf("unsupported KDBX version %d.%d, please save the database in KDBX 4 format", major, sig[2]&0xffff)
+	}
+
+	h, err := readOuterHeader(r)
+	if err != nil {
+		return nil, err
+	}
+	headerBytes := data[:len(data)-r.Len()]
+
+	var storedHash, storedHMAC [32]byte
+	if _, err := io.ReadFull(r, storedHash[:]); err != nil {
+		return nil, fmt.Errorf("truncated database")
+	}
+	if _, err := io.ReadFull(r, storedHMAC[:]); err != nil {
+		return nil, fmt.Errorf("truncated database")
+	}
+	if sha256.Sum256(headerBytes) != storedHash {
+		return nil, fmt.Errorf("header checksum mismatch, the database is corrupted")
+	}
+
+	composite, err := creds.compositeKey()
+	if err != nil {
+		return nil, err
+	}
+	transformed, err := transformKey(h.kdf, composite)
+	if err != nil {
+		return nil, err
+	}
+	masterKey, hmacKey := deriveKeys(h.masterSeed, transformed)
+
+	if !hmac.Equal(headerHMAC(hmacKey, headerBytes), storedHMAC[:]) {
+		return nil, ErrInvalidCredentials
+	}
+
+	payload, err := readBlocks(r, hmacKey)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := decryptPayload(h, masterKey, payload)
+	if err != nil {
+		return nil, err
+	}
+	if h.compressed {
+		if plaintext, err = decompress(plaintext); err != nil {
+			return nil, err
+		}
+	}
+
+	inner := bytes.NewReader(plaintext)
+	stream, err := readInnerHeader(inner)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc node
+	xmlData := bytes.TrimPrefix(plaintext[len(plaintext)-inner.Len():], []byte("\xef\xbb\xbf"))
+	if err := xml.Unmarshal(xmlData, &doc); err != nil {
+		return nil, fmt.Errorf("invalid database document: %w", err)
+	}
+	if err := processProtected(&doc, stream, false); err != nil {
+		return nil, err
+	}
+	return parseDocument(&doc)
+}
+
+// Write 以 AES-256 + Argon2id 加密、gzip 压缩的 KDBX 4 格式序列化数据库
+func Write(db *Database, creds Credentials) ([]byte, error) {
+	composite, err := creds.compositeKey()
+	if err != nil {
+		return nil, err
+	}
+
+	masterSeed, err := randomBytes(32)
+	if err != nil {
+		return nil, err
+	}
+	iv, err := randomBytes(aes.BlockSize)
+	if err != nil {
+		return nil, err
+	}
+	salt, err := randomBytes(32)
+	if err != nil {
+		return nil, err
+	}
+	streamKey, err := randomBytes(64)
+	if err != nil {
+		return nil, err
+	}
+
+	kdf := newArgon2idParams(salt)
+	transformed, err := transformKey(kdf, composite)
+	if err != nil {
+		return nil, err
+	}
+	masterKey, hmacKey := deriveKeys(masterSeed, transformed)
+
+	// 外层头部
+	var out bytes.Buffer
+	binary.Write(&out, binary.LittleEndian, [3]uint32{signature1, signature2, fileVersion4})
+	writeField(&out, headerCipherID, cipherAES256)
+	writeField(&out, headerCompression, binary.LittleEndian.AppendUint32(nil, 1))
+	writeField(&out, headerMasterSeed, masterSeed)
+	writeField(&out, headerEncryptionIV, iv)
+	writeField(&out, headerKdfParameters, kdf.marshal())
+	writeField(&out, headerEnd, []byte("\r\n\r\n"))
+	headerBytes := bytes.Clone(out.Bytes())
+	headerHash := sha256.Sum256(headerBytes)
+	out.Write(headerHash[:])
+	out.Write(headerHMAC(hmacKey, headerBytes))
+
+	// 内层头部与 XML 文档
+	var inner bytes.Buffer
+	writeField(&inner, innerHeaderStreamID, binary.LittleEndian.AppendUint32(nil, innerStreamChaCha20))
+	writeField(&inner, innerHeaderStreamKey, streamKey)
+	writeField(&inner, innerHeaderEnd, nil)
+
+	doc := db.document(time.Now())
+	stream, err := newInnerStream(streamKey)
+	if err != nil {
+		return nil, err
+	}
+	if err := processProtected(doc, stream, true); err != nil {
+		return nil, err
+	}
+	inner.WriteString(xml.Header)
+	enc := xml.NewEncoder(&inner)
+	enc.Indent("", "\t")
+	if err := enc.Encode(doc); err != nil {
+		return nil, fmt.Errorf("failed to encode database: %w", err)
+	}
+
+	var compressed bytes.Buffer
+	zw := gzip.NewWriter(&compressed)
+	zw.Write(inner.Bytes())
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to compress database: %w", err)
+	}
+
+	ciphertext, err := encryptCBC(masterKey, iv, compressed.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	writeBlocks(&out, hmacKey, ciphertext)
+	return out.Bytes(), nil
+}
+
+// readOuterHeader 读取外层头部直到结束标记
+func readOuterHeader(r *bytes.Reader) (*outerHeader, error) {
+	h := &outerHeader{}
+	for {
+		id, data, err := readField(r)
+		if err != nil {
+			return nil, err
+		}
+		switch id {
+		case headerEnd:
+			if h.cipherID == nil || len(h.masterSeed) != 32 || h.iv == nil || h.kdf == nil {
+				return nil, fmt.Errorf("incomplete database header")
+			}
+			return h, nil
+		case headerCipherID:
+			h.cipherID = data
+		case headerCompression:
+			if len(data) != 4 || binary.LittleEndian.Uint32(data) > 1 {
+				return nil, fmt.Errorf("unsupported compression")
+			}
+			h.compressed = binary.LittleEndian.Uint32(data) == 1
+		case headerMasterSeed:
+			h.masterSeed = data
+		case headerEncryptionIV:
+			h.iv = data
+		case headerKdfParameters:
+			if h.kdf, err = parseVariantDict(data); err != nil {
+				return nil, err
+			}
+		}
+	}
+}
+
+// readInnerHeader 读取内层头部并返回受保护值使用的内层流
+func readInnerHeader(r *bytes.Reader) (cipher.Stream, error) {
+	var streamID uint32
+	var streamKey []byte
+	for {
+		id, data, err := readField(r)
+		if err != nil {
+			return nil, err
+		}
+		switch id {
+		case innerHeaderEnd:
+			if streamID != innerStreamChaCha20 {
+				return nil, fmt.Errorf("unsupported inner stream: %d", streamID)
+			}
+			return newInnerStream(streamKey)
+		case innerHeaderStreamID:
+			if len(data) != 4 {
+				return nil, fmt.Errorf("invalid inner header")
+			}
+			streamID = binary.LittleEndian.Uint32(data)
+		case innerHeaderStreamKey:
+			streamKey = data
+		}
+	}
+}
+
+// readField 读取头部字段：id(1) + 长度(4) + 数据
+func readField(r *bytes.Reader) (byte, []byte, error) {
+	id, err := r.ReadByte()
+	if err != nil {
+		return 0, nil, fmt.Errorf("truncated header")
+	}
+	var size uint32
+	if err := binary.Read(r, binary.LittleEndian, &size); err != nil {
+		return 0, nil, fmt.Errorf("truncated header")
+	}
+	if int64(size) > int64(r.Len()) {
+		return 0, nil, fmt.Errorf("truncated header")
+	}
+	data := make([]byte, size)
+	io.ReadFull(r, data)
+	return id, data, nil
+}
+
+func writeField(w *bytes.Buffer, id byte, data []byte) {
+	w.WriteByte(id)
+	binary.Write(w, binary.LittleEndian, uint32(len(data)))
+	w.Write(data)
+}
+
+// deriveKeys 由主种子与变换后的密钥计算负载加密密钥和 HMAC 基础密钥
+func deriveKeys(masterSeed, transformed []byte) (masterKey, hmacKey []byte) {
+	sum := sha256.New()
+	sum.Write(masterSeed)
+	sum.Write(transformed)
+
+	h := sha512.New()
+	h.Write(masterSeed)
+	h.Write(transformed)
+	h.Write([]byte{1})
+	return sum.Sum(nil), h.Sum(nil)
+}
+
+// blockKey 第 index 个分块的 HMAC 密钥
+func blockKey(hmacKey []byte, index uint64) []byte {
+	h := sha512.New()
+	h.Write(binary.LittleEndian.AppendUint64(nil, index))
+	h.Write(hmacKey)
+	return h.Sum(nil)
+}
+
+// headerHMAC 头部 HMAC，使用索引为 2^64-1 的分块密钥
+func headerHMAC(hmacKey, header []byte) []byte {
+	mac := hmac.New(sha256.New, blockKey(hmacKey, math.MaxUint64))
+	mac.Write(header)
+	return mac.Sum(nil)
+}
+
+func blockHMAC(hmacKey []byte, index uint64, data []byte) []byte {
+	mac := hmac.New(sha256.New, blockKey(hmacKey, index))
+	mac.Write(binary.LittleEndian.AppendUint64(nil, index))
+	mac.Write(binary.LittleEndian.AppendUint32(nil, uint32(len(data))))
+	mac.Write(data)
+	return mac.Sum(nil)
+}
+
+// readBlocks 校验并拼接 HMAC 分块，长度为 0 的分块表示结束
+func readBlocks(r *bytes.Reader, hmacKey []byte) ([]byte, error) {
+	var payload bytes.Buffer
+	for index := uint64(0); ; index++ {
+		var mac [32]byte
+		var size uint32
+		if _, err := io.ReadFull(r, mac[:]); err != nil {
+			return nil, fmt.Errorf("truncated database")
+		}
+		if err := binary.Read(r, binary.LittleEndian, &size); err != nil {
+			return nil, fmt.Errorf("truncated database")
+		}
+		if int64(size) > int64(r.Len()) {
+			return nil, fmt.Errorf("truncated database")
+		}
+		data := make([]byte, size)
+		io.ReadFull(r, data)
+
+		if !hmac.Equal(blockHMAC(hmacKey, index, data), mac[:]) {
+			return nil, fmt.Errorf("block %d checksum mismatch, the database is corrupted", index)
+		}
+		if size == 0 {
+			return payload.Bytes(), nil
+		}
+		payload.Write(data)
+	}
+}
+
+func writeBlocks(w *bytes.Buffer, hmacKey, payload []byte) {
+	for index := uint64(0); ; index++ {
+		n := min(len(payload), blockSize)
+		data := payload[:n]
+		payload = payload[n:]
+
+		w.Write(blockHMAC(hmacKey, index, data))
+		binary.Write(w, binary.LittleEndian, uint32(n))
+		w.Write(data)
+		if n == 0 {
+			return
+		}
+	}
+}
+
+// decryptPayload 按头部声明的算法解密负载
+func decryptPayload(h *outerHeader, masterKey, payload []byte) ([]byte, error) {
+	switch {
+	case bytes.Equal(h.cipherID, cipherAES256):
+		return decryptCBC(masterKey, h.iv, payload)
+	case bytes.Equal(h.cipherID, cipherChaCha20):
+		c, err := chacha20.NewUnauthenticatedCipher(masterKey, h.iv)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ChaCha20 parameters: %w", err)
+		}
+		plaintext := make([]byte, len(payload))
+		c.XORKeyStream(plaintext, payload)
+		return plaintext, nil
+	case bytes.Equal(h.cipherID, cipherTwofish):
+		return nil, fmt.Errorf("Twofish encrypted databases are not supported, please switch the cipher to AES or ChaCha20")
+	default:
+		return nil, fmt.Errorf("unsupported cipher: %x", h.cipherID)
+	}
+}
+
+// decompress 解压 gzip 负载，解压后超过 maxDecompressedSize 时返回错误
+func decompress(data []byte) ([]byte, error) {
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress database: %w", err)
+	}
+	plaintext, err := io.ReadAll(io.LimitReader(zr, maxDecompressedSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress database: %w", err)
+	}
+	if len(plaintext) > maxDecompressedSize {
+		return nil, fmt.Errorf("decompressed database exceeds %d MiB", maxDecompressedSize>>20)
+	}
+	return plaintext, nil
+}
+
+// newInnerStream 内层流：密钥与 nonce 取自 SHA-512(streamKey)
+func newInnerStream(streamKey []byte) (cipher.Stream, error) {
+	sum := sha512.Sum512(streamKey)
+	c, err := chacha20.NewUnauthenticatedCipher(sum[:32], sum[32:44])
+	if err != nil {
+		return nil, fmt.Errorf("failed to create inner stream: %w", err)
+	}
+	return c, nil
+}
+
+// decryptCBC AES-256-CBC 解密并去除 PKCS#7 填充
+func decryptCBC(key, iv, ciphertext []byte) ([]byte, error) {
+	c, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	if len(iv) != aes.BlockSize || len(ciphertext) == 0 || len(ciphertext)%aes.BlockSize != 0 {
+		return nil, fmt.Errorf("invalid encrypted payload")
+	}
+
+	plaintext := make([]byte, len(ciphertext))
+	cipher.NewCBCDecrypter(c, iv).CryptBlocks(plaintext, ciphertext)
+
+	padding := int(plaintext[len(plaintext)-1])
+	if padding == 0 || padding > aes.BlockSize {
+		return nil, fmt.Errorf("invalid padding, the database is corrupted")
+	}
+	return plaintext[:len(plaintext)-padding], nil
+}
+
+// encryptCBC AES-256-CBC 加密，使用 PKCS#7 填充
+func encryptCBC(key, iv, plaintext []byte) ([]byte, error) {
+	c, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	padding := aes.BlockSize - len(plaintext)%aes.BlockSize
+	padded := append(bytes.Clone(plaintext), bytes.Repeat([]byte{byte(padding)}, padding)...)
+	cipher.NewCBCEncrypter(c, iv).CryptBlocks(padded, padded)
+	return padded, nil
+}
+
+func randomBytes(n int) ([]byte, error) {
+	b := make([]byte, n)
+	if _, err := io.ReadFull(rand.Reader, b); err != nil {
+		return nil, fmt.Errorf("failed to generate random bytes: %w", err)
+	}
+	return b, nil
+}
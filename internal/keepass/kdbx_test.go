@@ -0,0 +1,83 @@
+package keepass
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"testing"
+)
+
+func TestWriteReadWithKeyFile(t *testing.T) {
+	keyFile := []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<KeyFile>
+	<Meta><Version>2.0</Version></Meta>
+	<Key><Data Hash="AE216C2E">
+		0102030405060708090A0B0C0D0E0F10
+		1112131415161718191A1B1C1D1E1F20
+	</Data></Key>
+</KeyFile>`)
+	creds := Credentials{Password: "secret", KeyFile: keyFile}
+
+	db := NewDatabase("Test")
+	group := NewGroup("Work")
+	entry := NewEntry()
+	entry.Set("Title", "Example", false)
+	entry.Set("otp", "otpauth://totp/Example:alice?secret=JBSWY3DPEHPK3PXP", true)
+	entry.Set("Notes", "<not & xml>", false)
+	group.Entries = append(group.Entries, entry)
+	db.Root.Groups = append(db.Root.Groups, group)
+
+	data, err := Write(db, creds)
+	if err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	got, err := Read(data, creds)
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if got.Name != "Test" || len(got.Root.Groups) != 1 || got.Root.Groups[0].Name != "Work" {
+		t.Fatalf("unexpected database structure: %+v", got)
+	}
+	entries := got.Root.Groups[0].Entries
+	if len(entries) != 1 {
+		t.Fatalf("got %d entries, want 1", len(entries))
+	}
+	for _, key := range []string{"Title", "otp", "Notes"} {
+		if entries[0].Get(key) != entry.Get(key) {
+			t.Errorf("%s = %q, want %q", key, entries[0].Get(key), entry.Get(key))
+		}
+	}
+
+	if _, err := Read(data, Credentials{Password: "secret"}); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("missing key file: err = %v, want ErrInvalidCredentials", err)
+	}
+	if _, err := Read([]byte("not a database"), creds); !errors.Is(err, ErrInvalidFile) {
+		t.Errorf("garbage input: err = %v, want ErrInvalidFile", err)
+	}
+}
+
+func TestDecompressLimit(t *testing.T) {
+	compress := func(n int) []byte {
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		if _, err := zw.Write(make([]byte, n)); err != nil {
+			t.Fatal(err)
+		}
+		if err := zw.Close(); err != nil {
+			t.Fatal(err)
+		}
+		return buf.Bytes()
+	}
+
+	got, err := decompress(compress(maxDecompressedSize))
+	if err != nil {
+		t.Fatalf("decompress at the limit: %v", err)
+	}
+	if len(got) != maxDecompressedSize {
+		t.Errorf("got %d bytes, want %d", len(got), maxDecompressedSize)
+	}
+	if _, err := decompress(compress(maxDecompressedSize + 1)); err == nil {
+		t.Error("expected error when the decompressed size exceeds the limit")
+	}
+}
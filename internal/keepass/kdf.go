@@ -0,0 +1,244 @@
+package keepass
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// KDF 标识
+var (
+	kdfAES      = []byte{0xc9, 0xd9, 0xf3, 0x9a, 0x62, 0x8a, 0x44, 0x60, 0xbf, 0x74, 0x0d, 0x08, 0xc1, 0x8a, 0x4f, 0xea}
+	kdfAESKDBX4 = []byte{0x7c, 0x02, 0xbb, 0x82, 0x79, 0xa7, 0x4a, 0xc0, 0x92, 0x7d, 0x11, 0x4a, 0x00, 0x64, 0x82, 0x38}
+	kdfArgon2d  = []byte{0xef, 0x63, 0x6d, 0xdf, 0x8c, 0x29, 0x44, 0x4b, 0x91, 0xf7, 0xa9, 0xa4, 0x03, 0xe3, 0x0a, 0x0c}
+	kdfArgon2id = []byte{0x9e, 0x29, 0x8b, 0x19, 0x56, 0xdb, 0x47, 0x73, 0xb2, 0x3d, 0xfc, 0x3e, 0xc6, 0xf0, 0xa1, 0xe6}
+)
+
+// 导出时使用的 Argon2id 参数
+const (
+	exportArgonIterations = 2
+	exportArgonMemory     = 64 * 1024 * 1024 // 字节
+	exportArgonThreads    = 4
+)
+
+// 导入时允许的 KDF 参数上限，避免恶意文件在验证密码前耗尽内存或长时间占用 CPU
+const (
+	maxArgonMemory     = 1024 * 1024 * 1024 // 字节
+	maxArgonIterations = 1000
+	maxAESKDFRounds    = 100000000
+)
+
+// VariantDictionary 值类型
+const (
+	variantEnd       = 0x00
+	variantUInt32    = 0x04
+	variantUInt64    = 0x05
+	variantBool      = 0x08
+	variantInt32     = 0x0c
+	variantInt64     = 0x0d
+	variantString    = 0x18
+	variantByteArray = 0x42
+
+	variantVersion = 0x0100
+)
+
+type variantEntry struct {
+	kind  byte
+	key   string
+	value []byte
+}
+
+// variantDict KDBX 4 的 VariantDictionary，保留条目顺序
+type variantDict struct {
+	entries []variantEntry
+}
+
+// parseVariantDict 解析 VariantDictionary
+func parseVariantDict(data []byte) (*variantDict, error) {
+	if len(data) < 2 {
+		return nil, fmt.Errorf("invalid KDF parameters")
+	}
+	if binary.LittleEndian.Uint16(data)>>8 > variantVersion>>8 {
+		return nil, fmt.Errorf("unsupported KDF parameters version")
+	}
+	data = data[2:]
+
+	d := &variantDict{}
+	for {
+		if len(data) < 1 {
+			return nil, fmt.Errorf("invalid KDF parameters")
+		}
+		kind := data[0]
+		data = data[1:]
+		if kind == variantEnd {
+			return d, nil
+		}
+
+		var fields [2][]byte
+		for i := range fields {
+			if len(data) < 4 {
+				return nil, fmt.Errorf("invalid KDF parameters")
+			}
+			n := int(int32(binary.LittleEndian.Uint32(data)))
+			if n < 0 || len(data)-4 < n {
+				return nil, fmt.Errorf("invalid KDF parameters")
+			}
+			fields[i] = data[4 : 4+n]
+			data = data[4+n:]
+		}
+		d.entries = append(d.entries, variantEntry{kind: kind, key: string(fields[0]), value: fields[1]})
+	}
+}
+
+// marshal 序列化为 VariantDictionary
+func (d *variantDict) marshal() []byte {
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.LittleEndian, uint16(variantVersion))
+	for _, e := range d.entries {
+		buf.WriteByte(e.kind)
+		binary.Write(&buf, binary.LittleEndian, int32(len(e.key)))
+		buf.WriteString(e.key)
+		binary.Write(&buf, binary.LittleEndian, int32(len(e.value)))
+		buf.Write(e.value)
+	}
+	buf.WriteByte(variantEnd)
+	return buf.Bytes()
+}
+
+func (d *variantDict) get(key string) (variantEntry, bool) {
+	for _, e := range d.entries {
+		if e.key == key {
+			return e, true
+		}
+	}
+	return variantEntry{}, false
+}
+
+func (d *variantDict) set(kind byte, key string, value []byte) {
+	for i, e := range d.entries {
+		if e.key == key {
+			d.entries[i] = variantEntry{kind: kind, key: key, value: value}
+			return
+		}
+	}
+	d.entries = append(d.entries, variantEntry{kind: kind, key: key, value: value})
+}
+
+// bytes 读取字节数组条目
+func (d *variantDict) bytes(key string) []byte {
+	e, ok := d.get(key)
+	if !ok || e.kind != variantByteArray {
+		return nil
+	}
+	return e.value
+}
+
+// uint 读取整数条目，兼容 32 / 64 位
+func (d *variantDict) uint(key string) (uint64, bool) {
+	e, ok := d.get(key)
+	if !ok {
+		return 0, false
+	}
+	switch {
+	case (e.kind == variantUInt32 || e.kind == variantInt32) && len(e.value) == 4:
+		return uint64(binary.LittleEndian.Uint32(e.value)), true
+	case (e.kind == variantUInt64 || e.kind == variantInt64) && len(e.value) == 8:
+		return binary.LittleEndian.Uint64(e.value), true
+	}
+	return 0, false
+}
+
+func (d *variantDict) setBytes(key string, value []byte) {
+	d.set(variantByteArray, key, value)
+}
+
+func (d *variantDict) setUint32(key string, value uint32) {
+	d.set(variantUInt32, key, binary.LittleEndian.AppendUint32(nil, value))
+}
+
+func (d *variantDict) setUint64(key string, value uint64) {
+	d.set(variantUInt64, key, binary.LittleEndian.AppendUint64(nil, value))
+}
+
+// newArgon2idParams 生成导出用的 Argon2id 参数
+func newArgon2idParams(salt []byte) *variantDict {
+	d := &variantDict{}
+	d.setBytes("$UUID", kdfArgon2id)
+	d.setUint32("V", argon2Version)
+	d.setBytes("S", salt)
+	d.setUint32("P", exportArgonThreads)
+	d.setUint64("M", exportArgonMemory)
+	d.setUint64("I", exportArgonIterations)
+	return d
+}
+
+// transformKey 使用头部声明的 KDF 将复合密钥变换为 32 字节密钥
+func transformKey(params *variantDict, composite []byte) ([]byte, error) {
+	uuid := params.bytes("$UUID")
+	switch {
+	case bytes.Equal(uuid, kdfAES), bytes.Equal(uuid, kdfAESKDBX4):
+		rounds, ok := params.uint("R")
+		seed := params.bytes("S")
+		if !ok || len(seed) != 32 {
+			return nil, fmt.Errorf("invalid AES-KDF parameters")
+		}
+		if rounds > maxAESKDFRounds {
+			return nil, fmt.Errorf("AES-KDF rounds too large: %d", rounds)
+		}
+		return aesKDF(composite, seed, rounds)
+
+	case bytes.Equal(uuid, kdfArgon2d), bytes.Equal(uuid, kdfArgon2id):
+		salt := params.bytes("S")
+		iterations, ok1 := params.uint("I")
+		memory, ok2 := params.uint("M")
+		parallelism, ok3 := params.uint("P")
+		version, _ := params.uint("V")
+		if !ok1 || !ok2 || !ok3 || len(salt) == 0 || iterations == 0 || iterations > 1<<32-1 ||
+			memory < 1024 || memory/1024 > 1<<32-1 || parallelism == 0 || parallelism > 255 {
+			return nil, fmt.Errorf("invalid Argon2 parameters")
+		}
+		if memory > maxArgonMemory {
+			return nil, fmt.Errorf("Argon2 memory too large: %d MiB", memory/1024/1024)
+		}
+		if iterations > maxArgonIterations {
+			return nil, fmt.Errorf("Argon2 iterations too large: %d", iterations)
+		}
+		if version != argon2Version {
+			return nil, fmt.Errorf("unsupported Argon2 version: 0x%x", version)
+		}
+
+		secret, data := params.bytes("K"), params.bytes("A")
+		t, m, p := uint32(iterations), uint32(memory/1024), uint8(parallelism)
+		if bytes.Equal(uuid, kdfArgon2id) && len(secret) == 0 && len(data) == 0 {
+			return argon2.IDKey(composite, salt, t, m, p, 32), nil
+		}
+		mode := argon2d
+		if bytes.Equal(uuid, kdfArgon2id) {
+			mode = argon2id
+		}
+		return argon2Key(mode, composite, salt, secret, data, t, m, p, 32), nil
+
+	default:
+		return nil, fmt.Errorf("unsupported KDF: %x", uuid)
+	}
+}
+
+// aesKDF KeePass 的 AES-KDF：以 seed 为密钥对复合密钥做 rounds 轮 AES-ECB 加密后取 SHA-256
+func aesKDF(composite, seed []byte, rounds uint64) ([]byte, error) {
+	c, err := aes.NewCipher(seed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	key := make([]byte, len(composite))
+	copy(key, composite)
+	for i := uint64(0); i < rounds; i++ {
+		c.Encrypt(key[:16], key[:16])
+		c.Encrypt(key[16:], key[16:])
+	}
+	sum := sha256.Sum256(key)
+	return sum[:], nil
+}
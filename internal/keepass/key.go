@@ -0,0 +1,99 @@
+package keepass
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"strings"
+)
+
+// Credentials 打开数据库所需的凭据，密码与密钥文件至少提供一个
+type Credentials struct {
+	Password string
+	KeyFile  []byte // 密钥文件原始内容，为空表示不使用密钥文件
+}
+
+// keyFileXML KeePass XML 密钥文件（1.0 为 base64，2.0 为带校验的十六进制）
+type keyFileXML struct {
+	Meta struct {
+		Version string `xml:"Version"`
+	} `xml:"Meta"`
+	Key struct {
+		Data struct {
+			Hash  string `xml:"Hash,attr"`
+			Value string `xml:",chardata"`
+		} `xml:"Data"`
+	} `xml:"Key"`
+}
+
+// compositeKey 计算复合密钥 SHA256(SHA256(password) || keyfile)
+func (c Credentials) compositeKey() ([]byte, error) {
+	if c.Password == "" && len(c.KeyFile) == 0 {
+		return nil, fmt.Errorf("password or key file required")
+	}
+
+	h := sha256.New()
+	if c.Password != "" {
+		sum := sha256.Sum256([]byte(c.Password))
+		h.Write(sum[:])
+	}
+	if len(c.KeyFile) > 0 {
+		key, err := parseKeyFile(c.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		h.Write(key)
+	}
+	return h.Sum(nil), nil
+}
+
+// parseKeyFile 按 KeePass 的规则解析密钥文件：
+// XML 格式、32 字节二进制、64 位十六进制，其余内容取 SHA-256
+func parseKeyFile(data []byte) ([]byte, error) {
+	trimmed := bytes.TrimSpace(data)
+	if bytes.HasPrefix(trimmed, []byte("<?xml")) || bytes.HasPrefix(trimmed, []byte("<KeyFile")) {
+		var kf keyFileXML
+		if err := xml.Unmarshal(trimmed, &kf); err == nil && kf.Key.Data.Value != "" {
+			return parseXMLKeyFile(kf)
+		}
+	}
+
+	if len(data) == 32 {
+		return data, nil
+	}
+	if len(data) == 64 {
+		if key, err := hex.DecodeString(string(data)); err == nil {
+			return key, nil
+		}
+	}
+	sum := sha256.Sum256(data)
+	return sum[:], nil
+}
+
+// parseXMLKeyFile 解析 XML 密钥文件中的密钥数据
+func parseXMLKeyFile(kf keyFileXML) ([]byte, error) {
+	value := strings.Join(strings.Fields(kf.Key.Data.Value), "")
+
+	if strings.HasPrefix(kf.Meta.Version, "2.") {
+		key, err := hex.DecodeString(value)
+		if err != nil || len(key) != 32 {
+			return nil, fmt.Errorf("invalid key file data")
+		}
+		if kf.Key.Data.Hash != "" {
+			sum := sha256.Sum256(key)
+			if !strings.EqualFold(hex.EncodeToString(sum[:4]), kf.Key.Data.Hash) {
+				return nil, fmt.Errorf("key file checksum mismatch")
+			}
+		}
+		return key, nil
+	}
+
+	key, err := base64.StdEncoding.DecodeString(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid key file data: %w", err)
+	}
+	return key, nil
+}
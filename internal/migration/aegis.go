@@ -0,0 +1,452 @@
+package migration
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"google-authenticator/internal/otp"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/scrypt"
+)
+
+// Aegis Authenticator 导出格式
+// 参见 https://github.com/beemdevelopment/Aegis/blob/master/docs/vault.md
+
+const aegisSlotPassword = 1
+
+// 导出时使用的参数，与 Aegis 新建口令槽位的默认值一致
+const (
+	aegisDBVersion = 3
+	aegisScryptN   = 1 << 15
+	aegisScryptR   = 8
+	aegisScryptP   = 1
+	aegisSaltLen   = 32
+	aegisNonceLen  = 12
+)
+
+// 导入时允许的 scrypt 参数上限，避免派生时耗尽内存（内存约为 128 * N * r 字节）
+const (
+	aegisMaxScryptN      = 1 << 20
+	aegisMaxScryptR      = 32
+	aegisMaxScryptP      = 16
+	aegisMaxScryptMemory = 1 << 30
+)
+
+type aegisFile struct {
+	Version int             `json:"version"`
+	Header  aegisHeader     `json:"header"`
+	DB      json.RawMessage `json:"db"` // 明文为对象，加密时为 base64 字符串
+}
+
+type aegisHeader struct {
+	Slots  []aegisSlot  `json:"slots"`
+	Params *aegisParams `json:"params"`
+}
+
+type aegisSlot struct {
+	Type      int         `json:"type"`
+	UUID      string      `json:"uuid"`
+	Key       string      `json:"key"` // hex，被口令密钥加密的主密钥
+	KeyParams aegisParams `json:"key_params"`
+	N         int         `json:"n,omitempty"`
+	R         int         `json:"r,omitempty"`
+	P         int         `json:"p,omitempty"`
+	Salt      string      `json:"salt,omitempty"` // hex
+	Repaired  bool        `json:"repaired,omitempty"`
+	IsBackup  bool        `json:"is_backup,omitempty"`
+}
+
+type aegisParams struct {
+	Nonce string `json:"nonce"` // hex
+	Tag   string `json:"tag"`   // hex
+}
+
+type aegisDB struct {
+	Version int          `json:"version"`
+	Entries []aegisEntry `json:"entries"`
+	Groups  []aegisGroup `json:"groups,omitempty"`
+}
+
+type aegisGroup struct {
+	UUID string `json:"uuid"`
+	Name string `json:"name"`
+}
+
+type aegisEntry struct {
+	Type     string    `json:"type"` // totp / hotp / steam / yandex / motp
+	UUID     string    `json:"uuid"`
+	Name     string    `json:"name"`
+	Issuer   string    `json:"issuer"`
+	Note     string    `json:"note"`
+	Favorite bool      `json:"favorite"`
+	Icon     *string   `json:"icon"`
+	IconMime *string   `json:"icon_mime,omitempty"`
+	Group    string    `json:"group,omitempty"`  // db 版本 2：分组名称
+	Groups   []string  `json:"groups,omitempty"` // db 版本 3：分组 UUID 列表
+	Info     aegisInfo `json:"info"`
+}
+
+type aegisInfo struct {
+	Secret  string `json:"secret"`
+	Algo    string `json:"algo"`
+	Digits  int    `json:"digits"`
+	Period  int    `json:"period,omitempty"`
+	Counter int64  `json:"counter"`
+	Pin     string `json:"pin,omitempty"`
+}
+
+// IsAegisEncrypted 判断 Aegis 导出文件是否加密
+func IsAegisEncrypted(data []byte) bool {
+	var file aegisFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return false
+	}
+	return len(file.Header.Slots) > 0
+}
+
+// ParseAegisVault 解析 Aegis 导出的 JSON 文件（明文或加密）
+// password 仅用于加密格式，返回账户及被跳过条目的告警
+func ParseAegisVault(data []byte, password string) ([]otp.Account, []string, error) {
+	var file aegisFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, nil, fmt.Errorf("invalid Aegis vault: %w", err)
+	}
+	if file.Version != 1 {
+		return nil, nil, fmt.Errorf("unsupported Aegis vault version: %d", file.Version)
+	}
+
+	dbData := []byte(file.DB)
+	if len(file.Header.Slots) > 0 {
+		if password == "" {
+			return nil, nil, ErrPasswordRequired
+		}
+		plaintext, err := decryptAegisDB(file, password)
+		if err != nil {
+			return nil, nil, err
+		}
+		dbData = plaintext
+	}
+
+	var db aegisDB
+	if err := json.Unmarshal(dbData, &db); err != nil {
+		return nil, nil, fmt.Errorf("invalid Aegis database: %w", err)
+	}
+
+	groupNames := make(map[string]string, len(db.Groups))
+	for _, g := range db.Groups {
+		groupNames[g.UUID] = g.Name
+	}
+
+	var accounts []otp.Account
+	var warnings []string
+	for _, entry := range db.Entries {
+		label := entry.Name
+		if entry.Issuer != "" {
+			label = entry.Issuer + ":" + entry.Name
+		}
+
+		var acc otp.Account
+		var err error
+		switch strings.ToLower(entry.Type) {
+		case "totp", "hotp":
+			acc, err = newOTPAccount(entry.Name, entry.Issuer, entry.Info.Secret, entry.Info.Algo,
+				entry.Type, entry.Info.Digits, entry.Info.Period, entry.Info.Counter)
+		case "steam":
+			acc, err = newSteamAccount(entry.Name, entry.Issuer, entry.Info.Secret)
+		case "yandex", "motp":
+			acc, err = newPinAccount(entry.Name, entry.Issuer, entry.Info.Secret, entry.Info.Pin, entry.Type, entry.Info.Period)
+		default:
+			err = fmt.Errorf("unsupported entry type: %s", entry.Type)
+		}
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("%s: 已跳过 (%v)", label, err))
+			continue
+		}
+
+		acc.Note = entry.Note
+		if entry.Icon != nil && *entry.Icon != "" {
+			mime := "image/png"
+			if entry.IconMime != nil && *entry.IconMime != "" {
+				mime = *entry.IconMime
+			}
+			acc.Icon = "data:" + mime + ";base64," + *entry.Icon
+		}
+		acc.Group = entry.Group
+		for _, id := range entry.Groups {
+			if name, ok := groupNames[id]; ok {
+				acc.Group = name
+				break
+			}
+		}
+		accounts = append(accounts, acc)
+	}
+
+	return accounts, warnings, nil
+}
+
+// decryptAegisDB 通过口令槽位解出主密钥并解密数据库
+func decryptAegisDB(file aegisFile, password string) ([]byte, error) {
+	if file.Header.Params == nil {
+		return nil, fmt.Errorf("missing vault encryption parameters")
+	}
+
+	var encoded string
+	if err := json.Unmarshal(file.DB, &encoded); err != nil {
+		return nil, fmt.Errorf("invalid encrypted database: %w", err)
+	}
+	ciphertext, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("invalid encrypted database: %w", err)
+	}
+
+	// 只有槽位参数有效且 GCM 校验失败时才说明口令错误，
+	// 其他错误（如 scrypt 参数超限）原样返回，避免提示用户重新输入口令
+	var masterKey []byte
+	var lastErr error
+	wrongPassword := false
+	for _, slot := range file.Header.Slots {
+		if slot.Type != aegisSlotPassword {
+			continue
+		}
+		key, err := openAegisSlot(slot, password)
+		if err == nil {
+			masterKey = key
+			break
+		}
+		if errors.Is(err, ErrWrongPassword) {
+			wrongPassword = true
+		} else {
+			lastErr = err
+		}
+	}
+	if masterKey == nil {
+		switch {
+		case wrongPassword:
+			return nil, ErrWrongPassword
+		case lastErr != nil:
+			return nil, lastErr
+		default:
+			return nil, fmt.Errorf("vault has no password slot")
+		}
+	}
+
+	return openAegisParams(masterKey, *file.Header.Params, ciphertext)
+}
+
+// openAegisSlot 用口令派生的密钥解密槽位中的主密钥
+func openAegisSlot(slot aegisSlot, password string) ([]byte, error) {
+	salt, err := hex.DecodeString(slot.Salt)
+	if err != nil {
+		return nil, fmt.Errorf("invalid slot salt: %w", err)
+	}
+	encryptedKey, err := hex.DecodeString(slot.Key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid slot key: %w", err)
+	}
+
+	if err := validateAegisScrypt(slot); err != nil {
+		return nil, err
+	}
+
+	derived, err := scrypt.Key([]byte(password), salt, slot.N, slot.R, slot.P, 32)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive key: %w", err)
+	}
+	return openAegisParams(derived, slot.KeyParams, encryptedKey)
+}
+
+// validateAegisScrypt 检查槽位中的 scrypt 参数
+func validateAegisScrypt(slot aegisSlot) error {
+	if slot.N < 2 || slot.N > aegisMaxScryptN || slot.N&(slot.N-1) != 0 {
+		return fmt.Errorf("invalid scrypt N: %d", slot.N)
+	}
+	if slot.R < 1 || slot.R > aegisMaxScryptR {
+		return fmt.Errorf("invalid scrypt r: %d", slot.R)
+	}
+	if slot.P < 1 || slot.P > aegisMaxScryptP {
+		return fmt.Errorf("invalid scrypt p: %d", slot.P)
+	}
+	if 128*slot.N*slot.R > aegisMaxScryptMemory {
+		return fmt.Errorf("scrypt parameters require too much memory: N=%d r=%d", slot.N, slot.R)
+	}
+	return nil
+}
+
+// openAegisParams 按 Aegis 的 nonce/tag 参数进行 AES-GCM 解密
+func openAegisParams(key []byte, params aegisParams, ciphertext []byte) ([]byte, error) {
+	nonce, err := hex.DecodeString(params.Nonce)
+	if err != nil {
+		return nil, fmt.Errorf("invalid nonce: %w", err)
+	}
+	tag, err := hex.DecodeString(params.Tag)
+	if err != nil {
+		return nil, fmt.Errorf("invalid tag: %w", err)
+	}
+	return openGCM(key, nonce, append(append([]byte{}, ciphertext...), tag...))
+}
+
+// sealAegisParams 使用 AES-GCM 加密，返回不含 tag 的密文及 Aegis 的 nonce/tag 参数
+func sealAegisParams(key, plaintext []byte) ([]byte, aegisParams, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, aegisParams{}, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, aegisParams{}, fmt.Errorf("failed to create GCM: %w", err)
+	}
+	nonce := make([]byte, aegisNonceLen)
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, aegisParams{}, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	sealed := gcm.Seal(nil, nonce, plaintext, nil)
+	ciphertext, tag := sealed[:len(sealed)-gcm.Overhead()], sealed[len(sealed)-gcm.Overhead():]
+	return ciphertext, aegisParams{Nonce: hex.EncodeToString(nonce), Tag: hex.EncodeToString(tag)}, nil
+}
+
+// GenerateAegisVault 将账户导出为 Aegis 格式的 JSON（数据库版本 3）
+// password 为空时导出明文，否则使用 scrypt 口令槽位加密；Aegis 不支持 OCRA，这类账户被跳过并计入 skipped
+func GenerateAegisVault(accounts []otp.Account, password string) (data []byte, skipped int, err error) {
+	db := aegisDB{Version: aegisDBVersion, Entries: make([]aegisEntry, 0, len(accounts))}
+	groupIDs := make(map[string]string)
+	for _, acc := range accounts {
+		if strings.EqualFold(acc.Type, otp.TypeOCRA) {
+			skipped++
+			continue
+		}
+		entry := aegisEntryFromAccount(acc)
+		if acc.Group != "" {
+			id, ok := groupIDs[acc.Group]
+			if !ok {
+				id = uuid.NewString()
+				groupIDs[acc.Group] = id
+				db.Groups = append(db.Groups, aegisGroup{UUID: id, Name: acc.Group})
+			}
+			entry.Groups = []string{id}
+		}
+		db.Entries = append(db.Entries, entry)
+	}
+
+	dbData, err := json.Marshal(db)
+	if err != nil {
+		return nil, skipped, fmt.Errorf("failed to encode Aegis database: %w", err)
+	}
+
+	file := aegisFile{Version: 1, DB: dbData}
+	if password != "" {
+		if file, err = encryptAegisDB(dbData, password); err != nil {
+			return nil, skipped, err
+		}
+	}
+	data, err = json.MarshalIndent(file, "", "    ")
+	return data, skipped, err
+}
+
+// aegisEntryFromAccount 将账户转换为 Aegis 条目，图标仅导出 data URL 形式的图片
+func aegisEntryFromAccount(acc otp.Account) aegisEntry {
+	id := acc.ID
+	if _, err := uuid.Parse(id); err != nil {
+		id = uuid.NewString()
+	}
+
+	algorithm := strings.ToUpper(acc.Algorithm)
+	if algorithm == "" {
+		algorithm = "SHA1"
+	}
+	digits := acc.Digits
+	if digits == 0 {
+		digits = 6
+	}
+
+	entry := aegisEntry{
+		Type:   strings.ToLower(acc.Type),
+		UUID:   id,
+		Name:   acc.Name,
+		Issuer: acc.Issuer,
+		Note:   acc.Note,
+		Info: aegisInfo{
+			Secret: strings.TrimRight(strings.ToUpper(acc.Secret), "="),
+			Algo:   algorithm,
+			Digits: digits,
+		},
+	}
+	if entry.Type == "" {
+		entry.Type = "totp"
+	}
+	entry.Info.Pin = acc.Pin
+	if entry.Type == "hotp" {
+		entry.Info.Counter = acc.Counter
+	} else {
+		entry.Info.Period = acc.Period
+		if entry.Info.Period == 0 {
+			entry.Info.Period = 30
+		}
+	}
+
+	if rest, ok := strings.CutPrefix(acc.Icon, "data:"); ok {
+		meta, data, _ := strings.Cut(rest, ",")
+		if mime, ok := strings.CutSuffix(meta, ";base64"); ok && data != "" {
+			entry.Icon = &data
+			entry.IconMime = &mime
+		}
+	}
+	return entry
+}
+
+// encryptAegisDB 生成随机主密钥加密数据库，并用口令派生的密钥加密主密钥写入槽位
+func encryptAegisDB(dbData []byte, password string) (aegisFile, error) {
+	masterKey := make([]byte, 32)
+	salt := make([]byte, aegisSaltLen)
+	if _, err := io.ReadFull(rand.Reader, masterKey); err != nil {
+		return aegisFile{}, fmt.Errorf("failed to generate key: %w", err)
+	}
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return aegisFile{}, fmt.Errorf("failed to generate salt: %w", err)
+	}
+
+	derived, err := scrypt.Key([]byte(password), salt, aegisScryptN, aegisScryptR, aegisScryptP, 32)
+	if err != nil {
+		return aegisFile{}, fmt.Errorf("failed to derive key: %w", err)
+	}
+	encryptedKey, keyParams, err := sealAegisParams(derived, masterKey)
+	if err != nil {
+		return aegisFile{}, err
+	}
+	ciphertext, dbParams, err := sealAegisParams(masterKey, dbData)
+	if err != nil {
+		return aegisFile{}, err
+	}
+
+	encoded, err := json.Marshal(base64.StdEncoding.EncodeToString(ciphertext))
+	if err != nil {
+		return aegisFile{}, err
+	}
+	return aegisFile{
+		Version: 1,
+		Header: aegisHeader{
+			Slots: []aegisSlot{{
+				Type:      aegisSlotPassword,
+				UUID:      uuid.NewString(),
+				Key:       hex.EncodeToString(encryptedKey),
+				KeyParams: keyParams,
+				N:         aegisScryptN,
+				R:         aegisScryptR,
+				P:         aegisScryptP,
+				Salt:      hex.EncodeToString(salt),
+				Repaired:  true,
+			}},
+			Params: &dbParams,
+		},
+		DB: encoded,
+	}, nil
+}
@@ -0,0 +1,53 @@
+package migration
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"google-authenticator/internal/otp"
+)
+
+func TestParseAegisVaultEncrypted(t *testing.T) {
+	data := readTestdata(t, "aegis_encrypted.json")
+	if !IsAegisEncrypted(data) {
+		t.Fatal("IsAegisEncrypted = false, want true")
+	}
+
+	accounts, warnings, err := ParseAegisVault(data, "aegis-test")
+	if err != nil {
+		t.Fatalf("ParseAegisVault: %v", err)
+	}
+	if len(warnings) != 0 {
+		t.Errorf("unexpected warnings: %v", warnings)
+	}
+	checkAccounts(t, accounts,
+		otp.Account{Name: "alice@example.com", Issuer: "Example", Secret: "JBSWY3DPEHPK3PXP", Algorithm: "SHA1", Digits: 6, Type: "TOTP", Period: 30, Group: "Work", Note: "work mail"},
+		otp.Account{Name: "bob", Issuer: "Bank", Secret: "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ", Algorithm: "SHA256", Digits: 8, Type: "HOTP", Period: 30, Counter: 5},
+		otp.Account{Name: "gaben", Issuer: "Steam", Secret: "KRUGS4ZAONUG65LMMQQGEZJAMEQHGZLDOJSXI===", Algorithm: "SHA1", Digits: otp.SteamDigits, Type: otp.TypeSteam, Period: 30},
+		otp.Account{Name: "carol", Issuer: "Yandex", Secret: "GAYTEMZUGU3DOOBZMFRGGZDFMY======", Algorithm: "SHA256", Digits: otp.YandexDigits, Type: otp.TypeYandex, Period: 30, Pin: "5239"},
+	)
+}
+
+func TestParseAegisVaultWrongPassword(t *testing.T) {
+	data := readTestdata(t, "aegis_encrypted.json")
+
+	if _, _, err := ParseAegisVault(data, "wrong"); !errors.Is(err, ErrWrongPassword) {
+		t.Errorf("wrong password: err = %v, want ErrWrongPassword", err)
+	}
+	if _, _, err := ParseAegisVault(data, ""); !errors.Is(err, ErrPasswordRequired) {
+		t.Errorf("empty password: err = %v, want ErrPasswordRequired", err)
+	}
+}
+
+func TestParseAegisVaultRejectsHugeScryptN(t *testing.T) {
+	data := bytes.Replace(readTestdata(t, "aegis_encrypted.json"), []byte(`"n": 32768`), []byte(`"n": 1073741824`), 1)
+
+	_, _, err := ParseAegisVault(data, "aegis-test")
+	if err == nil {
+		t.Fatal("expected error for scrypt N above the limit")
+	}
+	if errors.Is(err, ErrWrongPassword) {
+		t.Errorf("got ErrWrongPassword, want the scrypt validation error: %v", err)
+	}
+}
@@ -0,0 +1,126 @@
+package migration
+
+import (
+	"bytes"
+	"crypto/pbkdf2"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"google-authenticator/internal/otp"
+)
+
+// andOTP 备份格式
+// 明文为 JSON 数组；加密备份（.json.aes）结构为：
+// iterations(4 字节大端) + salt(12) + iv(12) + AES-256-GCM 密文及 tag，
+// 密钥由 PBKDF2-HMAC-SHA1(password, salt, iterations) 派生
+
+const (
+	andOTPSaltLen  = 12
+	andOTPNonceLen = 12
+)
+
+type andOTPEntry struct {
+	Secret    string   `json:"secret"`
+	Issuer    string   `json:"issuer"`
+	Label     string   `json:"label"`
+	Digits    int      `json:"digits"`
+	Type      string   `json:"type"` // TOTP / HOTP / STEAM
+	Algorithm string   `json:"algorithm"`
+	Thumbnail string   `json:"thumbnail"`
+	Period    int      `json:"period"`
+	Counter   int64    `json:"counter"`
+	Tags      []string `json:"tags"`
+}
+
+// ParseAndOTPBackup 解析 andOTP 备份（明文 JSON 或 PBKDF2 加密的 .json.aes）
+func ParseAndOTPBackup(data []byte, password string) ([]otp.Account, []string, error) {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) == 0 || trimmed[0] != '[' {
+		if password == "" {
+			return nil, nil, ErrPasswordRequired
+		}
+		plaintext, err := decryptAndOTP(data, password)
+		if err != nil {
+			return nil, nil, err
+		}
+		trimmed = plaintext
+	}
+
+	var entries []andOTPEntry
+	if err := json.Unmarshal(trimmed, &entries); err != nil {
+		return nil, nil, fmt.Errorf("invalid andOTP backup: %w", err)
+	}
+
+	var accounts []otp.Account
+	var warnings []string
+	for _, entry := range entries {
+		issuer, name := entry.Issuer, entry.Label
+		// 旧版本 andOTP 没有 issuer 字段，标签形如 "Issuer - account"
+		if issuer == "" {
+			if parts := strings.SplitN(name, " - ", 2); len(parts) == 2 {
+				issuer, name = parts[0], parts[1]
+			}
+		}
+
+		var acc otp.Account
+		var err error
+		switch strings.ToUpper(entry.Type) {
+		case "STEAM":
+			acc, err = newSteamAccount(name, issuer, entry.Secret)
+		default:
+			acc, err = newOTPAccount(name, issuer, entry.Secret, entry.Algorithm,
+				entry.Type, entry.Digits, entry.Period, entry.Counter)
+		}
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("%s: 已跳过 (%v)", entry.Label, err))
+			continue
+		}
+
+		if len(entry.Tags) > 0 {
+			acc.Group = entry.Tags[0]
+			if len(entry.Tags) > 1 {
+				warnings = append(warnings, fmt.Sprintf("%s: 有多个标签，仅使用第一个 %q 作为分组", displayName(acc), entry.Tags[0]))
+			}
+		}
+		if entry.Thumbnail != "" && entry.Thumbnail != "Default" {
+			acc.Icon = entry.Thumbnail
+		}
+		accounts = append(accounts, acc)
+	}
+
+	return accounts, warnings, nil
+}
+
+// decryptAndOTP 解密 andOTP 加密备份
+// 优先按 PBKDF2 新格式解密，失败后尝试旧版本的 SHA-256(password) 密钥格式
+func decryptAndOTP(data []byte, password string) ([]byte, error) {
+	if len(data) > 4+andOTPSaltLen+andOTPNonceLen {
+		iterations := int(binary.BigEndian.Uint32(data[:4]))
+		salt := data[4 : 4+andOTPSaltLen]
+		nonce := data[4+andOTPSaltLen : 4+andOTPSaltLen+andOTPNonceLen]
+		ciphertext := data[4+andOTPSaltLen+andOTPNonceLen:]
+
+		if iterations > 0 && iterations <= 10000000 {
+			key, err := pbkdf2.Key(sha1.New, password, salt, iterations, 32)
+			if err != nil {
+				return nil, fmt.Errorf("failed to derive key: %w", err)
+			}
+			if plaintext, err := openGCM(key, nonce, ciphertext); err == nil {
+				return plaintext, nil
+			}
+		}
+	}
+
+	if len(data) > andOTPNonceLen {
+		key := sha256.Sum256([]byte(password))
+		if plaintext, err := openGCM(key[:], data[:andOTPNonceLen], data[andOTPNonceLen:]); err == nil {
+			return plaintext, nil
+		}
+	}
+
+	return nil, ErrWrongPassword
+}
@@ -0,0 +1,39 @@
+package migration
+
+import (
+	"errors"
+	"testing"
+
+	"google-authenticator/internal/otp"
+)
+
+func TestParseAndOTPBackupEncrypted(t *testing.T) {
+	for _, name := range []string{"andotp_encrypted.json.aes", "andotp_legacy.json.aes"} {
+		accounts, warnings, err := ParseAndOTPBackup(readTestdata(t, name), "andotp-test")
+		if err != nil {
+			t.Fatalf("%s: ParseAndOTPBackup: %v", name, err)
+		}
+		// 第一个账户有两个标签，只有第一个作为分组
+		if len(warnings) != 1 {
+			t.Errorf("%s: warnings = %v, want one multi-tag warning", name, warnings)
+		}
+		checkAccounts(t, accounts,
+			otp.Account{Name: "alice@example.com", Issuer: "Example", Secret: "JBSWY3DPEHPK3PXP", Algorithm: "SHA1", Digits: 6, Type: "TOTP", Period: 30, Group: "Work"},
+			otp.Account{Name: "bob", Issuer: "Bank", Secret: "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ", Algorithm: "SHA256", Digits: 8, Type: "HOTP", Period: 30, Counter: 5},
+			otp.Account{Name: "gaben", Issuer: "Steam", Secret: "KRUGS4ZAONUG65LMMQQGEZJAMEQHGZLDOJSXI===", Algorithm: "SHA1", Digits: otp.SteamDigits, Type: otp.TypeSteam, Period: 30},
+			// 旧版本没有 issuer 字段，从 "Issuer - account" 标签中拆分
+			otp.Account{Name: "dave", Issuer: "GitHub", Secret: "MFRGGZDFMZTWQ2LK", Algorithm: "SHA512", Digits: 6, Type: "TOTP", Period: 60},
+		)
+	}
+}
+
+func TestParseAndOTPBackupWrongPassword(t *testing.T) {
+	data := readTestdata(t, "andotp_encrypted.json.aes")
+
+	if _, _, err := ParseAndOTPBackup(data, "wrong"); !errors.Is(err, ErrWrongPassword) {
+		t.Errorf("wrong password: err = %v, want ErrWrongPassword", err)
+	}
+	if _, _, err := ParseAndOTPBackup(data, ""); !errors.Is(err, ErrPasswordRequired) {
+		t.Errorf("empty password: err = %v, want ErrPasswordRequired", err)
+	}
+}
@@ -0,0 +1,172 @@
+package migration
+
+import (
+	"bytes"
+	"crypto/pbkdf2"
+	"crypto/sha1"
+	"encoding/json"
+	"fmt"
+
+	"google-authenticator/internal/otp"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// Authenticator Pro 备份格式（.authpro）
+// 加密备份有两种：
+//   - 新格式：头部 "AUTHENTICATORPRO" + salt(16) + iv(12) + AES-256-GCM 密文，Argon2id 派生密钥
+//   - 旧格式：头部 "AuthenticatorPro" + salt(20) + iv(16) + AES-256-CBC 密文，PBKDF2-SHA1 派生密钥
+
+const (
+	authProHeader       = "AUTHENTICATORPRO"
+	authProLegacyHeader = "AuthenticatorPro"
+
+	authProSaltLen       = 16
+	authProNonceLen      = 12
+	authProArgonTime     = 3
+	authProArgonMemory   = 64 * 1024 // 64 MB
+	authProArgonThreads  = 4
+	authProLegacySaltLen = 20
+	authProLegacyIVLen   = 16
+	authProLegacyRounds  = 64000
+)
+
+// Authenticator Pro 的账户类型
+const (
+	authProTypeHOTP   = 1
+	authProTypeTOTP   = 2
+	authProTypeMOTP   = 3
+	authProTypeSteam  = 4
+	authProTypeYandex = 5
+)
+
+type authProBackup struct {
+	Authenticators          []authProAuthenticator `json:"Authenticators"`
+	Categories              []authProCategory      `json:"Categories"`
+	AuthenticatorCategories []authProBinding       `json:"AuthenticatorCategories"`
+}
+
+type authProAuthenticator struct {
+	Type      int    `json:"Type"`
+	Icon      string `json:"Icon"`
+	Issuer    string `json:"Issuer"`
+	Username  string `json:"Username"`
+	Secret    string `json:"Secret"`
+	Pin       string `json:"Pin"`
+	Algorithm int    `json:"Algorithm"` // 0 SHA1, 1 SHA256, 2 SHA512
+	Digits    int    `json:"Digits"`
+	Period    int    `json:"Period"`
+	Counter   int64  `json:"Counter"`
+}
+
+type authProCategory struct {
+	ID   string `json:"Id"`
+	Name string `json:"Name"`
+}
+
+type authProBinding struct {
+	CategoryID          string `json:"CategoryId"`
+	AuthenticatorSecret string `json:"AuthenticatorSecret"`
+}
+
+// ParseAuthenticatorProBackup 解析 Authenticator Pro 备份（明文 JSON 或加密 .authpro）
+func ParseAuthenticatorProBackup(data []byte, password string) ([]otp.Account, []string, error) {
+	if bytes.HasPrefix(data, []byte(authProHeader)) || bytes.HasPrefix(data, []byte(authProLegacyHeader)) {
+		if password == "" {
+			return nil, nil, ErrPasswordRequired
+		}
+		plaintext, err := decryptAuthPro(data, password)
+		if err != nil {
+			return nil, nil, err
+		}
+		data = plaintext
+	}
+
+	var backup authProBackup
+	if err := json.Unmarshal(data, &backup); err != nil {
+		return nil, nil, fmt.Errorf("invalid Authenticator Pro backup: %w", err)
+	}
+
+	categoryNames := make(map[string]string, len(backup.Categories))
+	for _, c := range backup.Categories {
+		categoryNames[c.ID] = c.Name
+	}
+	// 账户通过密钥与分类关联，一个账户可属于多个分类，取第一个
+	groups := make(map[string]string)
+	for _, b := range backup.AuthenticatorCategories {
+		if _, ok := groups[b.AuthenticatorSecret]; !ok {
+			groups[b.AuthenticatorSecret] = categoryNames[b.CategoryID]
+		}
+	}
+
+	var accounts []otp.Account
+	var warnings []string
+	for _, auth := range backup.Authenticators {
+		label := auth.Issuer + ":" + auth.Username
+
+		algorithm := "SHA1"
+		switch auth.Algorithm {
+		case 1:
+			algorithm = "SHA256"
+		case 2:
+			algorithm = "SHA512"
+		}
+
+		var acc otp.Account
+		var err error
+		switch auth.Type {
+		case authProTypeTOTP:
+			acc, err = newOTPAccount(auth.Username, auth.Issuer, auth.Secret, algorithm, "TOTP", auth.Digits, auth.Period, 0)
+		case authProTypeHOTP:
+			acc, err = newOTPAccount(auth.Username, auth.Issuer, auth.Secret, algorithm, "HOTP", auth.Digits, 0, auth.Counter)
+		case authProTypeSteam:
+			acc, err = newSteamAccount(auth.Username, auth.Issuer, auth.Secret)
+		case authProTypeYandex:
+			acc, err = newPinAccount(auth.Username, auth.Issuer, auth.Secret, auth.Pin, otp.TypeYandex, auth.Period)
+		case authProTypeMOTP:
+			// Authenticator Pro 保存 mOTP 原始的十六进制密钥
+			var secret string
+			if secret, err = otp.MOTPSecretFromHex(auth.Secret); err == nil {
+				acc, err = newPinAccount(auth.Username, auth.Issuer, secret, auth.Pin, otp.TypeMOTP, 0)
+			}
+		default:
+			err = fmt.Errorf("unknown authenticator type: %d", auth.Type)
+		}
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("%s: 已跳过 (%v)", label, err))
+			continue
+		}
+
+		acc.Group = groups[auth.Secret]
+		acc.Icon = auth.Icon
+		accounts = append(accounts, acc)
+	}
+
+	return accounts, warnings, nil
+}
+
+// decryptAuthPro 解密 .authpro 加密备份
+func decryptAuthPro(data []byte, password string) ([]byte, error) {
+	if bytes.HasPrefix(data, []byte(authProHeader)) {
+		body := data[len(authProHeader):]
+		if len(body) < authProSaltLen+authProNonceLen {
+			return nil, fmt.Errorf("invalid Authenticator Pro backup")
+		}
+		salt := body[:authProSaltLen]
+		nonce := body[authProSaltLen : authProSaltLen+authProNonceLen]
+		key := argon2.IDKey([]byte(password), salt, authProArgonTime, authProArgonMemory, authProArgonThreads, 32)
+		return openGCM(key, nonce, body[authProSaltLen+authProNonceLen:])
+	}
+
+	body := data[len(authProLegacyHeader):]
+	if len(body) < authProLegacySaltLen+authProLegacyIVLen {
+		return nil, fmt.Errorf("invalid Authenticator Pro backup")
+	}
+	salt := body[:authProLegacySaltLen]
+	iv := body[authProLegacySaltLen : authProLegacySaltLen+authProLegacyIVLen]
+	key, err := pbkdf2.Key(sha1.New, password, salt, authProLegacyRounds, 32)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive key: %w", err)
+	}
+	return openCBC(key, iv, body[authProLegacySaltLen+authProLegacyIVLen:])
+}
@@ -0,0 +1,42 @@
+package migration
+
+import (
+	"errors"
+	"testing"
+
+	"google-authenticator/internal/otp"
+)
+
+func TestParseAuthenticatorProBackupEncrypted(t *testing.T) {
+	// 新格式为 Argon2id + AES-GCM，旧格式为 PBKDF2-SHA1 + AES-CBC
+	for _, name := range []string{"authpro_encrypted.authpro", "authpro_legacy.authpro"} {
+		accounts, warnings, err := ParseAuthenticatorProBackup(readTestdata(t, name), "authpro-test")
+		if err != nil {
+			t.Fatalf("%s: ParseAuthenticatorProBackup: %v", name, err)
+		}
+		if len(warnings) != 0 {
+			t.Errorf("%s: unexpected warnings: %v", name, warnings)
+		}
+		checkAccounts(t, accounts,
+			otp.Account{Name: "alice@example.com", Issuer: "Example", Secret: "JBSWY3DPEHPK3PXP", Algorithm: "SHA1", Digits: 6, Type: "TOTP", Period: 30, Group: "Work"},
+			otp.Account{Name: "bob", Issuer: "Bank", Secret: "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ", Algorithm: "SHA256", Digits: 8, Type: "HOTP", Period: 30, Counter: 5},
+			otp.Account{Name: "gaben", Issuer: "Steam", Secret: "KRUGS4ZAONUG65LMMQQGEZJAMEQHGZLDOJSXI===", Algorithm: "SHA1", Digits: otp.SteamDigits, Type: otp.TypeSteam, Period: 30},
+			otp.Account{Name: "carol", Issuer: "Yandex", Secret: "GAYTEMZUGU3DOOBZMFRGGZDFMY======", Algorithm: "SHA256", Digits: otp.YandexDigits, Type: otp.TypeYandex, Period: 30, Pin: "5239"},
+			// mOTP 密钥在备份中为十六进制
+			otp.Account{Name: "frank", Issuer: "VPN", Secret: "4MKSV7XGEWM4Q===", Algorithm: "MD5", Digits: otp.MOTPDigits, Type: otp.TypeMOTP, Period: otp.MOTPPeriod, Pin: "1234"},
+		)
+	}
+}
+
+func TestParseAuthenticatorProBackupWrongPassword(t *testing.T) {
+	for _, name := range []string{"authpro_encrypted.authpro", "authpro_legacy.authpro"} {
+		data := readTestdata(t, name)
+
+		if _, _, err := ParseAuthenticatorProBackup(data, "wrong"); !errors.Is(err, ErrWrongPassword) {
+			t.Errorf("%s: wrong password: err = %v, want ErrWrongPassword", name, err)
+		}
+		if _, _, err := ParseAuthenticatorProBackup(data, ""); !errors.Is(err, ErrPasswordRequired) {
+			t.Errorf("%s: empty password: err = %v, want ErrPasswordRequired", name, err)
+		}
+	}
+}
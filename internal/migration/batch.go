@@ -0,0 +1,83 @@
+package migration
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+var (
+	ErrBatchMismatch = errors.New("migration page belongs to a different batch")
+)
+
+// BatchSession 多页迁移码的导入会话
+// 按 batch_id 收集各页账户，全部页扫描完成后才可取出账户
+type BatchSession struct {
+	BatchID   int32
+	BatchSize int
+	pages     map[int][]*OtpParameters
+}
+
+// NewBatchSession 以首个扫描到的页创建导入会话
+func NewBatchSession(info BatchInfo) *BatchSession {
+	return &BatchSession{
+		BatchID:   info.BatchID,
+		BatchSize: info.BatchSize,
+		pages:     make(map[int][]*OtpParameters),
+	}
+}
+
+// AddPage 加入一页迁移数据
+// 重复扫描同一页返回 false，不同批次的页返回 ErrBatchMismatch
+func (s *BatchSession) AddPage(info BatchInfo, params []*OtpParameters) (bool, error) {
+	if info.BatchID != s.BatchID {
+		return false, fmt.Errorf("%w: expected batch %d, got %d", ErrBatchMismatch, s.BatchID, info.BatchID)
+	}
+	if info.BatchSize != s.BatchSize {
+		return false, fmt.Errorf("%w: expected %d pages, got %d", ErrBatchMismatch, s.BatchSize, info.BatchSize)
+	}
+	if info.BatchIndex < 0 || info.BatchIndex >= s.BatchSize {
+		return false, fmt.Errorf("invalid batch index %d of %d", info.BatchIndex, s.BatchSize)
+	}
+
+	if _, ok := s.pages[info.BatchIndex]; ok {
+		return false, nil
+	}
+	s.pages[info.BatchIndex] = params
+	return true, nil
+}
+
+// Scanned 返回已扫描的页序号（从 0 开始，升序）
+func (s *BatchSession) Scanned() []int {
+	indexes := make([]int, 0, len(s.pages))
+	for i := range s.pages {
+		indexes = append(indexes, i)
+	}
+	sort.Ints(indexes)
+	return indexes
+}
+
+// Missing 返回尚未扫描的页序号（从 0 开始，升序）
+func (s *BatchSession) Missing() []int {
+	missing := make([]int, 0, s.BatchSize-len(s.pages))
+	for i := 0; i < s.BatchSize; i++ {
+		if _, ok := s.pages[i]; !ok {
+			missing = append(missing, i)
+		}
+	}
+	return missing
+}
+
+// Complete 检查是否所有页都已扫描
+func (s *BatchSession) Complete() bool {
+	return len(s.pages) == s.BatchSize
+}
+
+// Accounts 按页序返回全部账户，仅在 Complete 后调用
+func (s *BatchSession) Accounts() []*OtpParameters {
+	var accounts []*OtpParameters
+	for i := 0; i < s.BatchSize; i++ {
+		accounts = append(accounts, s.pages[i]...)
+	}
+	return accounts
+}
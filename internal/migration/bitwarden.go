@@ -0,0 +1,242 @@
+package migration
+
+import (
+	"crypto/hkdf"
+	"crypto/hmac"
+	"crypto/pbkdf2"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"google-authenticator/internal/otp"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// Bitwarden JSON 导出格式
+// 口令保护的导出中 data 为 EncString "2.iv|密文|mac"（AES-256-CBC + HMAC-SHA256），
+// 密钥由 PBKDF2-SHA256 或 Argon2id 派生后经 HKDF-Expand 拆分为加密密钥与 MAC 密钥
+
+const (
+	bitwardenKdfPBKDF2   = 0
+	bitwardenKdfArgon2id = 1
+	bitwardenItemLogin   = 1
+)
+
+// 导入时允许的 KDF 参数上限（与 Bitwarden 客户端允许的设置范围一致），避免恶意文件耗尽内存或长时间占用 CPU
+const (
+	bitwardenMaxPBKDF2Iterations = 2000000
+	bitwardenMaxArgonIterations  = 10
+	bitwardenMaxArgonMemory      = 1024 // MiB
+	bitwardenMaxArgonParallelism = 16
+)
+
+type bitwardenExport struct {
+	Encrypted         bool              `json:"encrypted"`
+	PasswordProtected bool              `json:"passwordProtected"`
+	Salt              string            `json:"salt"`
+	KdfType           int               `json:"kdfType"`
+	KdfIterations     int               `json:"kdfIterations"`
+	KdfMemory         int               `json:"kdfMemory"` // MiB
+	KdfParallelism    int               `json:"kdfParallelism"`
+	EncKeyValidation  string            `json:"encKeyValidation_DO_NOT_EDIT"`
+	Data              string            `json:"data"`
+	Folders           []bitwardenFolder `json:"folders"`
+	Items             []bitwardenItem   `json:"items"`
+}
+
+type bitwardenFolder struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type bitwardenItem struct {
+	Type     int             `json:"type"`
+	Name     string          `json:"name"`
+	Notes    string          `json:"notes"`
+	FolderID string          `json:"folderId"`
+	Login    *bitwardenLogin `json:"login"`
+}
+
+type bitwardenLogin struct {
+	Username string `json:"username"`
+	TOTP     string `json:"totp"`
+}
+
+// ParseBitwardenExport 解析 Bitwarden JSON 导出（未加密或口令保护），导入登录项中的 TOTP
+func ParseBitwardenExport(data []byte, password string) ([]otp.Account, []string, error) {
+	var export bitwardenExport
+	if err := json.Unmarshal(data, &export); err != nil {
+		return nil, nil, fmt.Errorf("invalid Bitwarden export: %w", err)
+	}
+
+	if export.Encrypted {
+		if !export.PasswordProtected {
+			return nil, nil, fmt.Errorf("account-restricted Bitwarden exports are not supported, please export with a file password")
+		}
+		if password == "" {
+			return nil, nil, ErrPasswordRequired
+		}
+		plaintext, err := decryptBitwarden(export, password)
+		if err != nil {
+			return nil, nil, err
+		}
+		export = bitwardenExport{}
+		if err := json.Unmarshal(plaintext, &export); err != nil {
+			return nil, nil, fmt.Errorf("invalid Bitwarden export: %w", err)
+		}
+	}
+
+	folderNames := make(map[string]string, len(export.Folders))
+	for _, f := range export.Folders {
+		folderNames[f.ID] = f.Name
+	}
+
+	var accounts []otp.Account
+	var warnings []string
+	for _, item := range export.Items {
+		if item.Type != bitwardenItemLogin || item.Login == nil || strings.TrimSpace(item.Login.TOTP) == "" {
+			continue
+		}
+
+		acc, itemWarnings, err := parseBitwardenTOTP(item)
+		warnings = append(warnings, itemWarnings...)
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("%s: 已跳过 (%v)", item.Name, err))
+			continue
+		}
+
+		acc.Group = folderNames[item.FolderID]
+		acc.Note = item.Notes
+		accounts = append(accounts, acc)
+	}
+
+	return accounts, warnings, nil
+}
+
+// parseBitwardenTOTP 解析登录项的 totp 字段：裸 base32 密钥、otpauth:// 或 steam:// URI
+func parseBitwardenTOTP(item bitwardenItem) (otp.Account, []string, error) {
+	value := strings.TrimSpace(item.Login.TOTP)
+	lower := strings.ToLower(value)
+
+	switch {
+	case strings.HasPrefix(lower, "otpauth://"):
+		key, err := ParseOTPAuthURI(value)
+		if err != nil {
+			return otp.Account{}, nil, err
+		}
+		acc := key.Account()
+		acc.Name = item.Name
+		var warnings []string
+		for _, w := range key.Warnings {
+			warnings = append(warnings, fmt.Sprintf("%s: %s", item.Name, w))
+		}
+		return acc, warnings, nil
+
+	case strings.HasPrefix(lower, "steam://"):
+		key, err := ParseSteamURI(value)
+		if err != nil {
+			return otp.Account{}, nil, err
+		}
+		acc := key.Account()
+		acc.Name = item.Name
+		return acc, nil, nil
+
+	default:
+		acc, err := newOTPAccount(item.Name, "", value, "SHA1", "TOTP", 6, 30, 0)
+		return acc, nil, err
+	}
+}
+
+// decryptBitwarden 派生口令密钥并解密导出数据
+func decryptBitwarden(export bitwardenExport, password string) ([]byte, error) {
+	if err := validateBitwardenKdf(export); err != nil {
+		return nil, err
+	}
+
+	var key []byte
+	switch export.KdfType {
+	case bitwardenKdfPBKDF2:
+		var err error
+		key, err = pbkdf2.Key(sha256.New, password, []byte(export.Salt), export.KdfIterations, 32)
+		if err != nil {
+			return nil, fmt.Errorf("failed to derive key: %w", err)
+		}
+	case bitwardenKdfArgon2id:
+		salt := sha256.Sum256([]byte(export.Salt))
+		key = argon2.IDKey([]byte(password), salt[:], uint32(export.KdfIterations),
+			uint32(export.KdfMemory)*1024, uint8(export.KdfParallelism), 32)
+	default:
+		return nil, fmt.Errorf("unsupported Bitwarden KDF type: %d", export.KdfType)
+	}
+
+	encKey, err := hkdf.Expand(sha256.New, key, "enc", 32)
+	if err != nil {
+		return nil, fmt.Errorf("failed to expand key: %w", err)
+	}
+	macKey, err := hkdf.Expand(sha256.New, key, "mac", 32)
+	if err != nil {
+		return nil, fmt.Errorf("failed to expand key: %w", err)
+	}
+
+	if export.EncKeyValidation != "" {
+		if _, err := openBitwardenEncString(export.EncKeyValidation, encKey, macKey); err != nil {
+			return nil, err
+		}
+	}
+	return openBitwardenEncString(export.Data, encKey, macKey)
+}
+
+// validateBitwardenKdf 检查导出文件中的 KDF 参数
+func validateBitwardenKdf(export bitwardenExport) error {
+	switch export.KdfType {
+	case bitwardenKdfPBKDF2:
+		if export.KdfIterations < 1 || export.KdfIterations > bitwardenMaxPBKDF2Iterations {
+			return fmt.Errorf("invalid PBKDF2 iterations: %d", export.KdfIterations)
+		}
+	case bitwardenKdfArgon2id:
+		if export.KdfIterations < 1 || export.KdfIterations > bitwardenMaxArgonIterations {
+			return fmt.Errorf("invalid Argon2id iterations: %d", export.KdfIterations)
+		}
+		if export.KdfMemory < 1 || export.KdfMemory > bitwardenMaxArgonMemory {
+			return fmt.Errorf("invalid Argon2id memory: %d MiB", export.KdfMemory)
+		}
+		if export.KdfParallelism < 1 || export.KdfParallelism > bitwardenMaxArgonParallelism {
+			return fmt.Errorf("invalid Argon2id parallelism: %d", export.KdfParallelism)
+		}
+	}
+	return nil
+}
+
+// openBitwardenEncString 解密类型 2 的 EncString：2.iv|ciphertext|mac（各段 base64）
+func openBitwardenEncString(encString string, encKey, macKey []byte) ([]byte, error) {
+	encType, payload, ok := strings.Cut(encString, ".")
+	if !ok || encType != "2" {
+		return nil, fmt.Errorf("unsupported Bitwarden encryption type: %s", encType)
+	}
+
+	parts := strings.Split(payload, "|")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("invalid Bitwarden encrypted string")
+	}
+	var decoded [3][]byte
+	for i := range decoded {
+		b, err := base64.StdEncoding.DecodeString(parts[i])
+		if err != nil {
+			return nil, fmt.Errorf("invalid Bitwarden encrypted string: %w", err)
+		}
+		decoded[i] = b
+	}
+	iv, ciphertext, mac := decoded[0], decoded[1], decoded[2]
+
+	h := hmac.New(sha256.New, macKey)
+	h.Write(iv)
+	h.Write(ciphertext)
+	if !hmac.Equal(h.Sum(nil), mac) {
+		return nil, ErrWrongPassword
+	}
+
+	return openCBC(encKey, iv, ciphertext)
+}
@@ -0,0 +1,45 @@
+package migration
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"strconv"
+
+	"google-authenticator/internal/otp"
+)
+
+// csvHeader CSV 导出的列
+var csvHeader = []string{"name", "issuer", "secret", "algorithm", "digits", "type", "period", "counter", "group", "pin", "ocra_suite"}
+
+// GenerateCSV 将账户导出为 CSV，首行为列名
+func GenerateCSV(accounts []otp.Account) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	if err := w.Write(csvHeader); err != nil {
+		return nil, fmt.Errorf("failed to write CSV: %w", err)
+	}
+	for _, acc := range accounts {
+		record := []string{
+			acc.Name,
+			acc.Issuer,
+			acc.Secret,
+			acc.Algorithm,
+			strconv.Itoa(acc.Digits),
+			acc.Type,
+			strconv.Itoa(acc.Period),
+			strconv.FormatInt(acc.Counter, 10),
+			acc.Group,
+			acc.Pin,
+			acc.OcraSuite,
+		}
+		if err := w.Write(record); err != nil {
+			return nil, fmt.Errorf("failed to write CSV: %w", err)
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return nil, fmt.Errorf("failed to write CSV: %w", err)
+	}
+	return buf.Bytes(), nil
+}
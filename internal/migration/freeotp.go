@@ -0,0 +1,59 @@
+package migration
+
+import (
+	"encoding/base32"
+	"encoding/json"
+	"fmt"
+
+	"google-authenticator/internal/otp"
+)
+
+// FreeOTP+ JSON 导出格式，secret 为有符号字节数组
+
+type freeOTPExport struct {
+	Tokens []freeOTPToken `json:"tokens"`
+}
+
+type freeOTPToken struct {
+	Algo      string `json:"algo"`
+	Counter   int64  `json:"counter"`
+	Digits    int    `json:"digits"`
+	IssuerExt string `json:"issuerExt"`
+	IssuerInt string `json:"issuerInt"`
+	Label     string `json:"label"`
+	Period    int    `json:"period"`
+	Secret    []int  `json:"secret"`
+	Type      string `json:"type"` // TOTP / HOTP
+}
+
+// ParseFreeOTPPlusBackup 解析 FreeOTP+ 的 JSON 导出
+func ParseFreeOTPPlusBackup(data []byte, _ string) ([]otp.Account, []string, error) {
+	var export freeOTPExport
+	if err := json.Unmarshal(data, &export); err != nil {
+		return nil, nil, fmt.Errorf("invalid FreeOTP+ backup: %w", err)
+	}
+
+	var accounts []otp.Account
+	var warnings []string
+	for _, token := range export.Tokens {
+		issuer := token.IssuerExt
+		if issuer == "" {
+			issuer = token.IssuerInt
+		}
+
+		secret := make([]byte, len(token.Secret))
+		for i, b := range token.Secret {
+			secret[i] = byte(int8(b))
+		}
+
+		acc, err := newOTPAccount(token.Label, issuer, base32.StdEncoding.EncodeToString(secret),
+			token.Algo, token.Type, token.Digits, token.Period, token.Counter)
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("%s:%s: 已跳过 (%v)", issuer, token.Label, err))
+			continue
+		}
+		accounts = append(accounts, acc)
+	}
+
+	return accounts, warnings, nil
+}
@@ -0,0 +1,180 @@
+package migration
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"strings"
+
+	"google-authenticator/internal/otp"
+)
+
+// 第三方备份导入的公共错误
+var (
+	ErrPasswordRequired = errors.New("backup is encrypted, password required")
+	ErrWrongPassword    = errors.New("wrong password or corrupted backup")
+)
+
+// normalizeAlgorithm 将各应用的算法写法统一为 SHA1 / SHA256 / SHA512 / MD5
+func normalizeAlgorithm(algo string) (string, error) {
+	normalized := strings.ToUpper(algo)
+	normalized = strings.TrimPrefix(normalized, "HMAC")
+	normalized = strings.NewReplacer("-", "", "_", "").Replace(normalized)
+	switch normalized {
+	case "", "SHA1":
+		return "SHA1", nil
+	case "SHA256", "SHA512", "MD5":
+		return normalized, nil
+	default:
+		return "", fmt.Errorf("unsupported algorithm: %s", algo)
+	}
+}
+
+// normalizeSecret 校验 base32 密钥并转换为带填充的标准形式
+func normalizeSecret(secret string) (string, error) {
+	raw, err := otp.DecodeSecret(secret)
+	if err != nil {
+		return "", fmt.Errorf("invalid secret: %w", err)
+	}
+	if len(raw) == 0 {
+		return "", fmt.Errorf("empty secret")
+	}
+	return base32.StdEncoding.EncodeToString(raw), nil
+}
+
+// newOTPAccount 根据通用字段构造 otp.Account，并校验密钥与参数
+// otpType 为 TOTP / HOTP / STEAM，digits、period 为 0 时使用默认值；STEAM 的位数、周期和算法固定
+func newOTPAccount(name, issuer, secret, algo, otpType string, digits, period int, counter int64) (otp.Account, error) {
+	normalizedSecret, err := normalizeSecret(secret)
+	if err != nil {
+		return otp.Account{}, err
+	}
+	algorithm, err := normalizeAlgorithm(algo)
+	if err != nil {
+		return otp.Account{}, err
+	}
+
+	otpType = strings.ToUpper(otpType)
+	switch otpType {
+	case "", "TOTP":
+		otpType = "TOTP"
+	case "HOTP":
+	case otp.TypeSteam:
+		algorithm, digits, period = "SHA1", otp.SteamDigits, 30
+	default:
+		return otp.Account{}, fmt.Errorf("unsupported OTP type: %s", otpType)
+	}
+
+	if digits == 0 {
+		digits = 6
+	}
+	if digits < 1 || digits > 10 {
+		return otp.Account{}, fmt.Errorf("unsupported digit count: %d", digits)
+	}
+	if period <= 0 {
+		period = 30
+	}
+
+	return otp.Account{
+		Name:      name,
+		Issuer:    issuer,
+		Secret:    normalizedSecret,
+		Algorithm: algorithm,
+		Digits:    digits,
+		Type:      otpType,
+		Counter:   counter,
+		Period:    period,
+	}, nil
+}
+
+// newSteamAccount 构造 Steam 令牌账户
+func newSteamAccount(name, issuer, secret string) (otp.Account, error) {
+	return newOTPAccount(name, issuer, secret, "SHA1", otp.TypeSteam, otp.SteamDigits, 30, 0)
+}
+
+// newPinAccount 构造需要 PIN 的 Yandex Key 或 mOTP 账户，secret 为 base32
+// 位数、算法固定，Yandex 周期 period 为 0 时使用 30 秒，mOTP 周期固定 10 秒
+func newPinAccount(name, issuer, secret, pin, otpType string, period int) (otp.Account, error) {
+	normalizedSecret, err := normalizeSecret(secret)
+	if err != nil {
+		return otp.Account{}, err
+	}
+	if pin == "" {
+		return otp.Account{}, fmt.Errorf("missing PIN")
+	}
+
+	acc := otp.Account{
+		Name:   name,
+		Issuer: issuer,
+		Secret: normalizedSecret,
+		Type:   strings.ToUpper(otpType),
+		Pin:    pin,
+	}
+	switch acc.Type {
+	case otp.TypeYandex:
+		acc.Algorithm, acc.Digits, acc.Period = "SHA256", otp.YandexDigits, period
+		if acc.Period <= 0 {
+			acc.Period = 30
+		}
+	case otp.TypeMOTP:
+		acc.Algorithm, acc.Digits, acc.Period = "MD5", otp.MOTPDigits, otp.MOTPPeriod
+	default:
+		return otp.Account{}, fmt.Errorf("unsupported OTP type: %s", otpType)
+	}
+	return acc, nil
+}
+
+// displayName 返回用于告警信息的账户名称
+func displayName(acc otp.Account) string {
+	if acc.Issuer != "" && acc.Name != "" {
+		return acc.Issuer + ":" + acc.Name
+	}
+	if acc.Name != "" {
+		return acc.Name
+	}
+	return acc.Issuer
+}
+
+// openGCM 使用 AES-GCM 解密，tag 附在密文末尾
+func openGCM(key, nonce, ciphertext []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	gcm, err := cipher.NewGCMWithNonceSize(block, len(nonce))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create GCM: %w", err)
+	}
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, ErrWrongPassword
+	}
+	return plaintext, nil
+}
+
+// openCBC 使用 AES-CBC 解密并去除 PKCS#7 填充
+func openCBC(key, iv, ciphertext []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	if len(iv) != block.BlockSize() || len(ciphertext) == 0 || len(ciphertext)%block.BlockSize() != 0 {
+		return nil, ErrWrongPassword
+	}
+
+	plaintext := make([]byte, len(ciphertext))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(plaintext, ciphertext)
+
+	padding := int(plaintext[len(plaintext)-1])
+	if padding == 0 || padding > block.BlockSize() {
+		return nil, ErrWrongPassword
+	}
+	for _, b := range plaintext[len(plaintext)-padding:] {
+		if int(b) != padding {
+			return nil, ErrWrongPassword
+		}
+	}
+	return plaintext[:len(plaintext)-padding], nil
+}
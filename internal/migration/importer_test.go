@@ -0,0 +1,35 @@
+package migration
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"google-authenticator/internal/otp"
+)
+
+// readTestdata 读取 testdata 下的样本文件，样本来源见 testdata/README.md
+func readTestdata(t *testing.T, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+// checkAccounts 比较导入结果中与验证码相关的字段及分组
+func checkAccounts(t *testing.T, got []otp.Account, want ...otp.Account) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("got %d accounts, want %d: %+v", len(got), len(want), got)
+	}
+	for i, w := range want {
+		g := got[i]
+		if g.Name != w.Name || g.Issuer != w.Issuer || g.Secret != w.Secret || g.Algorithm != w.Algorithm ||
+			g.Digits != w.Digits || g.Type != w.Type || g.Period != w.Period || g.Counter != w.Counter ||
+			g.Pin != w.Pin || g.Group != w.Group || g.Note != w.Note {
+			t.Errorf("account %d = %+v, want %+v", i, g, w)
+		}
+	}
+}
@@ -0,0 +1,240 @@
+package migration
+
+import (
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"google-authenticator/internal/keepass"
+	"google-authenticator/internal/otp"
+)
+
+// KeePass 数据库中的 TOTP 有三种保存方式：
+//   - KeePassXC：otp 字段保存 otpauth:// URI（早期 KeeOtp 插件为 key=...&step=... 查询串）
+//   - KeeTrayTOTP：TOTP Seed 保存 base32 密钥，TOTP Settings 为 "周期;位数"，位数为 S 表示 Steam
+//   - KeePass 2.47+ 内置：TimeOtp-* / HmacOtp-* 字段
+// 分组路径以 "/" 连接映射到 Group，根分组下的条目不设分组
+
+const (
+	keePassDatabaseName = "Google Authenticator"
+	keePassGroupSep     = "/"
+)
+
+// ParseKeePassDatabase 解析 KeePass KDBX 4 数据库（仅使用密码）
+func ParseKeePassDatabase(data []byte, password string) ([]otp.Account, []string, error) {
+	return ParseKeePassDatabaseWithKeyFile(data, password, nil)
+}
+
+// ParseKeePassDatabaseWithKeyFile 使用密码和/或密钥文件解析 KDBX 4 数据库，导入含 TOTP 的条目
+func ParseKeePassDatabaseWithKeyFile(data []byte, password string, keyFile []byte) ([]otp.Account, []string, error) {
+	if password == "" && len(keyFile) == 0 {
+		return nil, nil, ErrPasswordRequired
+	}
+
+	db, err := keepass.Read(data, keepass.Credentials{Password: password, KeyFile: keyFile})
+	if err != nil {
+		if errors.Is(err, keepass.ErrInvalidCredentials) {
+			return nil, nil, ErrWrongPassword
+		}
+		return nil, nil, err
+	}
+
+	var accounts []otp.Account
+	var warnings []string
+	var walk func(g *keepass.Group, path string)
+	walk = func(g *keepass.Group, path string) {
+		if db.RecycleBinUUID != "" && g.UUID == db.RecycleBinUUID {
+			return
+		}
+		for _, e := range g.Entries {
+			acc, entryWarnings, ok, err := keePassEntryAccount(e)
+			if !ok {
+				continue
+			}
+			warnings = append(warnings, entryWarnings...)
+			if err != nil {
+				warnings = append(warnings, fmt.Sprintf("%s: 已跳过 (%v)", e.Get("Title"), err))
+				continue
+			}
+			acc.Group = path
+			accounts = append(accounts, acc)
+		}
+		for _, sub := range g.Groups {
+			subPath := sub.Name
+			if path != "" {
+				subPath = path + keePassGroupSep + sub.Name
+			}
+			walk(sub, subPath)
+		}
+	}
+	walk(db.Root, "")
+
+	return accounts, warnings, nil
+}
+
+// keePassEntryAccount 从条目字段中提取 TOTP 账户，ok 为 false 表示条目不含 TOTP
+func keePassEntryAccount(e *keepass.Entry) (acc otp.Account, warnings []string, ok bool, err error) {
+	// 标题作为发行方、用户名作为账户名；没有用户名时以标题作为账户名
+	title := e.Get("Title")
+	name := e.Get("UserName")
+	if name == "" {
+		name, title = title, ""
+	}
+
+	switch {
+	case strings.TrimSpace(e.Get("otp")) != "":
+		acc, warnings, err = parseKeePassOTPField(strings.TrimSpace(e.Get("otp")), name, title)
+
+	case strings.TrimSpace(e.Get("TOTP Seed")) != "":
+		period, digits := 30, 6
+		settings := strings.Split(e.Get("TOTP Settings"), ";")
+		if len(settings) >= 1 && strings.TrimSpace(settings[0]) != "" {
+			period, _ = strconv.Atoi(strings.TrimSpace(settings[0]))
+		}
+		if len(settings) >= 2 {
+			if strings.EqualFold(strings.TrimSpace(settings[1]), "S") {
+				acc, err = newSteamAccount(name, title, e.Get("TOTP Seed"))
+				break
+			}
+			digits, _ = strconv.Atoi(strings.TrimSpace(settings[1]))
+		}
+		acc, err = newOTPAccount(name, title, e.Get("TOTP Seed"), "SHA1", "TOTP", digits, period, 0)
+
+	default:
+		acc, ok, err = parseKeePassNativeOTP(e, name, title)
+		if !ok {
+			return otp.Account{}, nil, false, nil
+		}
+	}
+
+	acc.Note = e.Get("Notes")
+	return acc, warnings, true, err
+}
+
+// parseKeePassOTPField 解析 otp 字段：otpauth:// URI 或 KeeOtp 查询串
+func parseKeePassOTPField(value, name, title string) (otp.Account, []string, error) {
+	if strings.HasPrefix(strings.ToLower(value), "otpauth://") {
+		key, err := ParseOTPAuthURI(value)
+		if err != nil {
+			return otp.Account{}, nil, err
+		}
+		if key.Name == "" {
+			key.Name = name
+		}
+		acc := key.Account()
+		var warnings []string
+		for _, w := range key.Warnings {
+			warnings = append(warnings, fmt.Sprintf("%s: %s", displayName(acc), w))
+		}
+		return acc, warnings, nil
+	}
+
+	query, err := url.ParseQuery(value)
+	if err != nil || query.Get("key") == "" {
+		return otp.Account{}, nil, fmt.Errorf("unrecognized otp value")
+	}
+	digits, _ := strconv.Atoi(query.Get("size"))
+	period, _ := strconv.Atoi(query.Get("step"))
+	counter, _ := strconv.ParseInt(query.Get("counter"), 10, 64)
+	acc, err := newOTPAccount(name, title, query.Get("key"), query.Get("otpHashMode"),
+		query.Get("type"), digits, period, counter)
+	return acc, nil, err
+}
+
+// parseKeePassNativeOTP 解析 KeePass 内置的 TimeOtp-* / HmacOtp-* 字段
+func parseKeePassNativeOTP(e *keepass.Entry, name, title string) (otp.Account, bool, error) {
+	if secret, ok, err := keePassNativeSecret(e, "TimeOtp-Secret"); ok {
+		if err != nil {
+			return otp.Account{}, true, err
+		}
+		digits, _ := strconv.Atoi(e.Get("TimeOtp-Length"))
+		period, _ := strconv.Atoi(e.Get("TimeOtp-Period"))
+		acc, err := newOTPAccount(name, title, secret, e.Get("TimeOtp-Algorithm"), "TOTP", digits, period, 0)
+		return acc, true, err
+	}
+
+	if secret, ok, err := keePassNativeSecret(e, "HmacOtp-Secret"); ok {
+		if err != nil {
+			return otp.Account{}, true, err
+		}
+		counter, _ := strconv.ParseInt(e.Get("HmacOtp-Counter"), 10, 64)
+		acc, err := newOTPAccount(name, title, secret, "SHA1", "HOTP", 6, 0, counter)
+		return acc, true, err
+	}
+
+	return otp.Account{}, false, nil
+}
+
+// keePassNativeSecret 读取 UTF-8 / Hex / Base32 / Base64 形式的内置密钥字段，统一返回 base32
+func keePassNativeSecret(e *keepass.Entry, prefix string) (string, bool, error) {
+	if v := e.Get(prefix + "-Base32"); v != "" {
+		return v, true, nil
+	}
+
+	var raw []byte
+	var err error
+	switch {
+	case e.Get(prefix+"-Hex") != "":
+		raw, err = hex.DecodeString(strings.Join(strings.Fields(e.Get(prefix+"-Hex")), ""))
+	case e.Get(prefix+"-Base64") != "":
+		raw, err = base64.StdEncoding.DecodeString(strings.TrimSpace(e.Get(prefix + "-Base64")))
+	case e.Get(prefix) != "":
+		raw = []byte(e.Get(prefix))
+	default:
+		return "", false, nil
+	}
+	if err != nil {
+		return "", true, fmt.Errorf("invalid secret: %w", err)
+	}
+	return base32.StdEncoding.EncodeToString(raw), true, nil
+}
+
+// GenerateKeePassDatabase 将账户导出为新的 KDBX 4 数据库
+// 每个账户生成一个条目，TOTP 以 otpauth:// URI 保存在受保护的 otp 字段中（KeePassXC 格式）
+func GenerateKeePassDatabase(accounts []otp.Account, password string, keyFile []byte) ([]byte, error) {
+	if password == "" && len(keyFile) == 0 {
+		return nil, fmt.Errorf("password or key file required")
+	}
+
+	db := keepass.NewDatabase(keePassDatabaseName)
+	for _, acc := range accounts {
+		group := db.Root
+		if acc.Group != "" {
+			for _, name := range strings.Split(acc.Group, keePassGroupSep) {
+				group = keePassSubgroup(group, name)
+			}
+		}
+
+		title := acc.Issuer
+		if title == "" {
+			title = acc.Name
+		}
+		entry := keepass.NewEntry()
+		entry.Set("Title", title, false)
+		entry.Set("UserName", acc.Name, false)
+		entry.Set("Password", "", true)
+		entry.Set("URL", "", false)
+		entry.Set("Notes", acc.Note, false)
+		entry.Set("otp", BuildOTPAuthURI(acc), true)
+		group.Entries = append(group.Entries, entry)
+	}
+
+	return keepass.Write(db, keepass.Credentials{Password: password, KeyFile: keyFile})
+}
+
+// keePassSubgroup 查找或创建指定名称的子分组
+func keePassSubgroup(parent *keepass.Group, name string) *keepass.Group {
+	for _, g := range parent.Groups {
+		if g.Name == name {
+			return g
+		}
+	}
+	g := keepass.NewGroup(name)
+	parent.Groups = append(parent.Groups, g)
+	return g
+}
@@ -0,0 +1,70 @@
+package migration
+
+import (
+	"errors"
+	"testing"
+
+	"google-authenticator/internal/otp"
+)
+
+func TestKeePassDatabaseRoundTrip(t *testing.T) {
+	accounts := []otp.Account{
+		{Name: "alice@example.com", Issuer: "Example", Secret: "JBSWY3DPEHPK3PXP", Algorithm: "SHA1", Digits: 6, Type: "TOTP", Period: 30, Group: "Work/Mail", Note: "primary"},
+		{Name: "bob", Issuer: "Bank", Secret: "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ", Algorithm: "SHA256", Digits: 8, Type: "TOTP", Period: 60},
+		{Name: "carol", Issuer: "VPN", Secret: "JBSWY3DPEHPK3PXP", Algorithm: "SHA1", Digits: 6, Type: "HOTP", Counter: 42},
+	}
+
+	data, err := GenerateKeePassDatabase(accounts, "correct horse", nil)
+	if err != nil {
+		t.Fatalf("GenerateKeePassDatabase: %v", err)
+	}
+
+	got, warnings, err := ParseKeePassDatabase(data, "correct horse")
+	if err != nil {
+		t.Fatalf("ParseKeePassDatabase: %v", err)
+	}
+	if len(warnings) != 0 {
+		t.Errorf("unexpected warnings: %v", warnings)
+	}
+	if len(got) != len(accounts) {
+		t.Fatalf("got %d accounts, want %d", len(got), len(accounts))
+	}
+
+	byName := make(map[string]otp.Account, len(got))
+	for _, acc := range got {
+		byName[acc.Name] = acc
+	}
+	for _, want := range accounts {
+		acc, ok := byName[want.Name]
+		if !ok {
+			t.Errorf("account %q missing after round trip", want.Name)
+			continue
+		}
+		if acc.Issuer != want.Issuer || acc.Secret != want.Secret || acc.Algorithm != want.Algorithm ||
+			acc.Digits != want.Digits || acc.Type != want.Type || acc.Group != want.Group || acc.Note != want.Note {
+			t.Errorf("account %q = %+v, want %+v", want.Name, acc, want)
+		}
+		if want.Type == "TOTP" && acc.Period != want.Period {
+			t.Errorf("account %q period = %d, want %d", want.Name, acc.Period, want.Period)
+		}
+		if want.Type == "HOTP" && acc.Counter != want.Counter {
+			t.Errorf("account %q counter = %d, want %d", want.Name, acc.Counter, want.Counter)
+		}
+	}
+}
+
+func TestKeePassDatabaseWrongPassword(t *testing.T) {
+	data, err := GenerateKeePassDatabase([]otp.Account{
+		{Name: "alice", Issuer: "Example", Secret: "JBSWY3DPEHPK3PXP", Algorithm: "SHA1", Digits: 6, Type: "TOTP", Period: 30},
+	}, "correct horse", nil)
+	if err != nil {
+		t.Fatalf("GenerateKeePassDatabase: %v", err)
+	}
+
+	if _, _, err := ParseKeePassDatabase(data, "battery staple"); !errors.Is(err, ErrWrongPassword) {
+		t.Errorf("wrong password: err = %v, want ErrWrongPassword", err)
+	}
+	if _, _, err := ParseKeePassDatabase(data, ""); !errors.Is(err, ErrPasswordRequired) {
+		t.Errorf("empty password: err = %v, want ErrPasswordRequired", err)
+	}
+}
@@ -4,8 +4,11 @@ import (
 	"encoding/base32"
 	"fmt"
 	"net/url"
+	"sort"
 	"strconv"
 	"strings"
+
+	"google-authenticator/internal/otp"
 )
 
 // Algorithm represents the hash algorithm
@@ -86,10 +89,116 @@ func (t OtpType) String() string {
 	}
 }
 
+// Account 将迁移参数转换为 otp.Account（不含 ID）
+// Google 迁移协议不携带周期，TOTP 固定为 30 秒
+func (p *OtpParameters) Account() otp.Account {
+	return otp.Account{
+		Name:      p.Name,
+		Issuer:    p.Issuer,
+		Secret:    base32.StdEncoding.EncodeToString(p.Secret),
+		Algorithm: p.Algorithm.String(),
+		Digits:    p.Digits.ToInt(),
+		Type:      p.Type.String(),
+		Counter:   p.Counter,
+		Period:    30,
+	}
+}
+
+// KeyURI 标准 otpauth:// Key URI 的完整解析结果
+// 参见 https://github.com/google/google-authenticator/wiki/Key-Uri-Format
+type KeyURI struct {
+	Type      OtpType
+	Name      string
+	Issuer    string
+	Secret    []byte
+	Algorithm Algorithm
+	Digits    int               // 1-10
+	Period    int               // TOTP 周期（秒）
+	Counter   int64             // HOTP 计数器
+	Variant   string            // 非标准令牌：otp.TypeSteam、otp.TypeYandex、otp.TypeMOTP 或 otp.TypeOCRA，为空表示标准 TOTP/HOTP
+	Pin       string            // Yandex Key、mOTP 和 OCRA 的 PIN
+	OcraSuite string            // OCRA 套件
+	Unknown   map[string]string // 未识别的查询参数，保留供调用方提示
+	Warnings  []string          // 解析过程中的非致命问题
+}
+
+// Account 将解析结果转换为 otp.Account（不含 ID）
+func (k *KeyURI) Account() otp.Account {
+	switch k.Variant {
+	case otp.TypeSteam:
+		return otp.Account{
+			Name:      k.Name,
+			Issuer:    k.Issuer,
+			Secret:    base32.StdEncoding.EncodeToString(k.Secret),
+			Algorithm: "SHA1",
+			Digits:    otp.SteamDigits,
+			Type:      otp.TypeSteam,
+			Period:    30,
+		}
+	case otp.TypeYandex:
+		return otp.Account{
+			Name:      k.Name,
+			Issuer:    k.Issuer,
+			Secret:    base32.StdEncoding.EncodeToString(k.Secret),
+			Algorithm: "SHA256",
+			Digits:    otp.YandexDigits,
+			Type:      otp.TypeYandex,
+			Period:    k.Period,
+			Pin:       k.Pin,
+		}
+	case otp.TypeMOTP:
+		return otp.Account{
+			Name:      k.Name,
+			Issuer:    k.Issuer,
+			Secret:    base32.StdEncoding.EncodeToString(k.Secret),
+			Algorithm: "MD5",
+			Digits:    otp.MOTPDigits,
+			Type:      otp.TypeMOTP,
+			Period:    otp.MOTPPeriod,
+			Pin:       k.Pin,
+		}
+	case otp.TypeOCRA:
+		return otp.Account{
+			Name:      k.Name,
+			Issuer:    k.Issuer,
+			Secret:    base32.StdEncoding.EncodeToString(k.Secret),
+			Algorithm: k.Algorithm.String(),
+			Digits:    k.Digits,
+			Type:      otp.TypeOCRA,
+			Counter:   k.Counter,
+			Pin:       k.Pin,
+			OcraSuite: k.OcraSuite,
+		}
+	}
+	return otp.Account{
+		Name:      k.Name,
+		Issuer:    k.Issuer,
+		Secret:    base32.StdEncoding.EncodeToString(k.Secret),
+		Algorithm: k.Algorithm.String(),
+		Digits:    k.Digits,
+		Type:      k.Type.String(),
+		Counter:   k.Counter,
+		Period:    k.Period,
+	}
+}
+
+// knownKeyURIParams Key URI 规范定义的查询参数
+var knownKeyURIParams = map[string]bool{
+	"secret":    true,
+	"issuer":    true,
+	"algorithm": true,
+	"digits":    true,
+	"period":    true,
+	"counter":   true,
+	"encoder":   true,
+	"pin":       true,
+	"ocrasuite": true,
+}
+
 // ParseOTPAuthURI parses standard otpauth:// URI
-func ParseOTPAuthURI(uri string) (*OtpParameters, error) {
+func ParseOTPAuthURI(uri string) (*KeyURI, error) {
 	// Check if it's a valid otpauth URI
-	if !strings.HasPrefix(uri, "otpauth://") {
+	if !strings.HasPrefix(strings.ToLower(uri), "otpauth://") {
 		return nil, fmt.Errorf("invalid URI scheme, expected otpauth://")
 	}
 
@@ -98,38 +207,86 @@ func ParseOTPAuthURI(uri string) (*OtpParameters, error) {
 		return nil, fmt.Errorf("failed to parse URI: %w", err)
 	}
 
-	// Get OTP type from scheme (totp or hotp)
-	otpType := OtpTypeTOTP
-	if u.Host == "hotp" {
+	// Get OTP type from host (totp, hotp, steam, yaotp, motp or ocra)
+	var otpType OtpType
+	variant := ""
+	switch strings.ToLower(u.Host) {
+	case "totp":
+		otpType = OtpTypeTOTP
+	case "steam":
+		otpType = OtpTypeTOTP
+		variant = otp.TypeSteam
+	case "yaotp":
+		otpType = OtpTypeTOTP
+		variant = otp.TypeYandex
+	case "motp":
+		otpType = OtpTypeTOTP
+		variant = otp.TypeMOTP
+	case "ocra":
+		otpType = OtpTypeTOTP
+		variant = otp.TypeOCRA
+	case "hotp":
 		otpType = OtpTypeHOTP
-	} else if u.Host != "totp" {
+	default:
 		return nil, fmt.Errorf("unsupported OTP type: %s", u.Host)
 	}
 
 	// Parse label (path) which contains issuer:account or just account
-	label := strings.TrimPrefix(u.Path, "/")
+	label, err := url.PathUnescape(strings.TrimPrefix(u.EscapedPath(), "/"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode label: %w", err)
+	}
 	if label == "" {
 		return nil, fmt.Errorf("missing label in URI")
 	}
 
-	// Decode URL-encoded label
-	label, err = url.QueryUnescape(label)
-	if err != nil {
-		return nil, fmt.Errorf("failed to decode label: %w", err)
+	key := &KeyURI{
+		Type:      otpType,
+		Algorithm: AlgorithmSHA1, // Default
+		Digits:    6,             // Default
+		Period:    30,            // Default
+		Variant:   variant,
+		Unknown:   make(map[string]string),
 	}
 
-	// Extract name and issuer from label
-	var name, issuer string
-	if strings.Contains(label, ":") {
-		parts := strings.SplitN(label, ":", 2)
-		issuer = parts[0]
-		name = parts[1]
+	// Extract name and issuer from label, account name may be preceded by spaces
+	if i := strings.Index(label, ":"); i >= 0 {
+		key.Issuer = strings.TrimSpace(label[:i])
+		key.Name = strings.TrimSpace(label[i+1:])
 	} else {
-		name = label
+		key.Name = strings.TrimSpace(label)
 	}
 
 	// Parse query parameters
 	query := u.Query()
+	names := make([]string, 0, len(query))
+	for name := range query {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if !knownKeyURIParams[strings.ToLower(name)] {
+			key.Unknown[name] = strings.Join(query[name], ",")
+			key.Warnings = append(key.Warnings, fmt.Sprintf("unknown parameter: %s", name))
+		}
+	}
+
+	// KeePassXC 等以 encoder=steam 标记 Steam 令牌
+	if encoder := query.Get("encoder"); encoder != "" {
+		if strings.EqualFold(encoder, "steam") && otpType == OtpTypeTOTP && variant == "" {
+			key.Variant = otp.TypeSteam
+		} else {
+			key.Warnings = append(key.Warnings, fmt.Sprintf("unsupported encoder: %s", encoder))
+		}
+	}
+
+	// Yandex Key 和 mOTP 需要 PIN
+	if variant == otp.TypeYandex || variant == otp.TypeMOTP {
+		key.Pin = query.Get("pin")
+		if key.Pin == "" {
+			return nil, fmt.Errorf("missing pin parameter")
+		}
+	}
 
 	// Secret (required)
 	secretStr := query.Get("secret")
@@ -137,51 +294,59 @@ func ParseOTPAuthURI(uri string) (*OtpParameters, error) {
 		return nil, fmt.Errorf("missing secret parameter")
 	}
 
-	// Decode base32 secret
-	secret, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(strings.ToUpper(secretStr))
+	// Decode base32 secret, padding is optional
+	key.Secret, err = otp.DecodeSecret(secretStr)
 	if err != nil {
 		return nil, fmt.Errorf("failed to decode secret: %w", err)
 	}
-
-	// Create OtpParameters
-	param := &OtpParameters{
-		Secret:    secret,
-		Name:      name,
-		Issuer:    issuer,
-		Algorithm: AlgorithmSHA1,    // Default
-		Digits:    DigitCountSix,    // Default
-		Type:      otpType,
+	if len(key.Secret) == 0 {
+		return nil, fmt.Errorf("empty secret")
 	}
 
-	// Parse optional issuer parameter (overrides label issuer)
-	if issuerParam := query.Get("issuer"); issuerParam != "" {
-		param.Issuer = issuerParam
+	// Issuer parameter takes precedence over the label prefix
+	if issuerParam := strings.TrimSpace(query.Get("issuer")); issuerParam != "" {
+		if key.Issuer != "" && key.Issuer != issuerParam {
+			key.Warnings = append(key.Warnings,
+				fmt.Sprintf("label issuer %q differs from issuer parameter %q", key.Issuer, issuerParam))
+		}
+		key.Issuer = issuerParam
 	}
 
 	// Parse algorithm
 	if algo := query.Get("algorithm"); algo != "" {
-		switch strings.ToUpper(algo) {
+		switch strings.ToUpper(strings.ReplaceAll(algo, "-", "")) {
 		case "SHA1":
-			param.Algorithm = AlgorithmSHA1
+			key.Algorithm = AlgorithmSHA1
 		case "SHA256":
-			param.Algorithm = AlgorithmSHA256
+			key.Algorithm = AlgorithmSHA256
 		case "SHA512":
-			param.Algorithm = AlgorithmSHA512
+			key.Algorithm = AlgorithmSHA512
 		case "MD5":
-			param.Algorithm = AlgorithmMD5
+			key.Algorithm = AlgorithmMD5
 		default:
 			return nil, fmt.Errorf("unsupported algorithm: %s", algo)
 		}
 	}
 
 	// Parse digits
-	if digits := query.Get("digits"); digits != "" {
-		if digits == "8" {
-			param.Digits = DigitCountEight
-		} else if digits == "6" {
-			param.Digits = DigitCountSix
+	if digitsStr := query.Get("digits"); digitsStr != "" {
+		digits, err := strconv.Atoi(digitsStr)
+		if err != nil || digits < 1 || digits > 10 {
+			return nil, fmt.Errorf("unsupported digit count: %s", digitsStr)
+		}
+		key.Digits = digits
+	}
+
+	// Parse period (for TOTP)
+	if periodStr := query.Get("period"); periodStr != "" {
+		period, err := strconv.Atoi(periodStr)
+		if err != nil || period <= 0 {
+			return nil, fmt.Errorf("invalid period value: %s", periodStr)
+		}
+		if otpType == OtpTypeHOTP {
+			key.Warnings = append(key.Warnings, "period parameter ignored for HOTP")
 		} else {
-			return nil, fmt.Errorf("unsupported digit count: %s", digits)
+			key.Period = period
 		}
 	}
 
@@ -192,13 +357,146 @@ func ParseOTPAuthURI(uri string) (*OtpParameters, error) {
 			return nil, fmt.Errorf("HOTP requires counter parameter")
 		}
 		counter, err := strconv.ParseInt(counterStr, 10, 64)
+		if err != nil || counter < 0 {
+			return nil, fmt.Errorf("invalid counter value: %s", counterStr)
+		}
+		key.Counter = counter
+	}
+
+	// OCRA 的算法和位数由套件决定，计数器参数可选
+	if variant == otp.TypeOCRA {
+		suite, err := otp.ParseOCRASuite(query.Get("ocrasuite"))
 		if err != nil {
-			return nil, fmt.Errorf("invalid counter value: %w", err)
+			return nil, err
+		}
+		key.OcraSuite = suite.Suite
+		key.Pin = query.Get("pin")
+		if suite.PinHash != "" && key.Pin == "" {
+			return nil, fmt.Errorf("missing pin parameter")
+		}
+		switch suite.Algorithm {
+		case "SHA256":
+			key.Algorithm = AlgorithmSHA256
+		case "SHA512":
+			key.Algorithm = AlgorithmSHA512
+		default:
+			key.Algorithm = AlgorithmSHA1
+		}
+		key.Digits = suite.Digits
+		if counterStr := query.Get("counter"); counterStr != "" {
+			key.Counter, err = strconv.ParseInt(counterStr, 10, 64)
+			if err != nil || key.Counter < 0 {
+				return nil, fmt.Errorf("invalid counter value: %s", counterStr)
+			}
+		}
+	}
+
+	return key, nil
+}
+
+// ParseKeyURI 解析 otpauth:// 或 steam:// URI
+func ParseKeyURI(uri string) (*KeyURI, error) {
+	if strings.HasPrefix(strings.ToLower(uri), "steam://") {
+		return ParseSteamURI(uri)
+	}
+	return ParseOTPAuthURI(uri)
+}
+
+// ParseSteamURI 解析 steam://<base32 密钥> 形式的 Steam 令牌（Bitwarden 等使用）
+func ParseSteamURI(uri string) (*KeyURI, error) {
+	if !strings.HasPrefix(strings.ToLower(uri), "steam://") {
+		return nil, fmt.Errorf("invalid URI scheme, expected steam://")
+	}
+	secret, err := otp.DecodeSecret(uri[len("steam://"):])
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode secret: %w", err)
+	}
+	if len(secret) == 0 {
+		return nil, fmt.Errorf("empty secret")
+	}
+	return &KeyURI{
+		Type:      OtpTypeTOTP,
+		Name:      "Steam",
+		Issuer:    "Steam",
+		Secret:    secret,
+		Algorithm: AlgorithmSHA1,
+		Digits:    otp.SteamDigits,
+		Period:    30,
+		Variant:   otp.TypeSteam,
+		Unknown:   make(map[string]string),
+	}, nil
+}
+
+// BuildOTPAuthURI 按 Key URI 规范生成 otpauth:// URI
+// Steam 令牌使用 KeePassXC 的 encoder=steam 扩展，Yandex Key、mOTP 和 OCRA 使用 yaotp、motp、ocra 类型和 pin、ocrasuite 参数
+func BuildOTPAuthURI(acc otp.Account) string {
+	label := acc.Name
+	if acc.Issuer != "" {
+		label = acc.Issuer + ":" + acc.Name
+	}
+
+	query := url.Values{}
+	query.Set("secret", strings.TrimRight(strings.ToUpper(acc.Secret), "="))
+	if acc.Issuer != "" {
+		query.Set("issuer", acc.Issuer)
+	}
+	algorithm := strings.ToUpper(acc.Algorithm)
+	if algorithm == "" {
+		algorithm = "SHA1"
+	}
+	query.Set("algorithm", algorithm)
+	digits := acc.Digits
+	if digits == 0 {
+		digits = 6
+	}
+	query.Set("digits", strconv.Itoa(digits))
+
+	otpType := "totp"
+	switch strings.ToUpper(acc.Type) {
+	case otp.TypeHOTP:
+		otpType = "hotp"
+		query.Set("counter", strconv.FormatInt(acc.Counter, 10))
+	case otp.TypeSteam:
+		query.Set("algorithm", "SHA1")
+		query.Set("digits", strconv.Itoa(otp.SteamDigits))
+		query.Set("period", "30")
+		query.Set("encoder", "steam")
+	case otp.TypeYandex:
+		otpType = "yaotp"
+		period := acc.Period
+		if period == 0 {
+			period = 30
+		}
+		query.Set("algorithm", "SHA256")
+		query.Set("digits", strconv.Itoa(otp.YandexDigits))
+		query.Set("period", strconv.Itoa(period))
+		query.Set("pin", acc.Pin)
+	case otp.TypeMOTP:
+		otpType = "motp"
+		query.Set("algorithm", "MD5")
+		query.Set("digits", strconv.Itoa(otp.MOTPDigits))
+		query.Set("period", strconv.Itoa(otp.MOTPPeriod))
+		query.Set("pin", acc.Pin)
+	case otp.TypeOCRA:
+		otpType = "ocra"
+		query.Del("algorithm")
+		query.Del("digits")
+		query.Set("ocrasuite", acc.OcraSuite)
+		query.Set("counter", strconv.FormatInt(acc.Counter, 10))
+		if acc.Pin != "" {
+			query.Set("pin", acc.Pin)
+		}
+	default:
+		period := acc.Period
+		if period == 0 {
+			period = 30
 		}
-		param.Counter = counter
+		query.Set("period", strconv.Itoa(period))
 	}
 
-	return param, nil
+	// 查询参数中的空格编码为 %20，部分应用不识别 "+"
+	return fmt.Sprintf("otpauth://%s/%s?%s", otpType, url.PathEscape(label),
+		strings.ReplaceAll(query.Encode(), "+", "%20"))
 }
 
 // GenerateSecretKey generates a random base32 secret key
@@ -1,12 +1,13 @@
 package migration
 
 import (
+	"crypto/rand"
 	"encoding/base64"
+	"encoding/binary"
 	"fmt"
 	"io"
 	"net/url"
 	"strings"
-	"time"
 )
 
 // Simple protobuf wire format parser for Google Authenticator migration
@@ -60,33 +61,62 @@ func (d *decoder) string() (string, error) {
 	return string(b), err
 }
 
+// BatchInfo 迁移数据的批次信息（Google Authenticator 导出多个二维码时使用）
+type BatchInfo struct {
+	Version    int   `json:"version"`
+	BatchSize  int   `json:"batch_size"`  // 二维码总页数
+	BatchIndex int   `json:"batch_index"` // 当前页序号，从 0 开始
+	BatchID    int32 `json:"batch_id"`    // 同一次导出的所有页共享
+
+	explicitIndex bool // batch_index 字段是否显式出现，仅旧版本本工具会写入值为 0 的 batch_index
+}
+
+// maxBatchSize 迁移码页数上限，超出视为无效数据
+const maxBatchSize = 1000
+
+// IsMultiBatch 判断当前页是否属于需要拼装的多页导出
+// accountCount 为当前页包含的账户数
+func (b BatchInfo) IsMultiBatch(accountCount int) bool {
+	if b.BatchSize <= 1 {
+		return false
+	}
+	// 旧版本本工具导出时将 batch_size 写成了账户数量，并显式写入值为 0 的 batch_index，
+	// batch_id 取自时间戳的低 6 位十进制数，这类单页迁移码不应进入多页拼装流程。
+	// Google Authenticator 和当前版本按 proto3 约定省略值为 0 的 batch_index，不会被误判
+	if b.explicitIndex && b.BatchIndex == 0 && b.BatchSize == accountCount && b.BatchID >= 0 && b.BatchID < 1000000 {
+		return false
+	}
+	return true
+}
+
 // ParseMigrationURISimple parses otpauth-migration:// URI
-func ParseMigrationURISimple(uri string) ([]*OtpParameters, error) {
+func ParseMigrationURISimple(uri string) ([]*OtpParameters, BatchInfo, error) {
 	if !strings.HasPrefix(uri, "otpauth-migration://") {
-		return nil, fmt.Errorf("invalid URI scheme")
+		return nil, BatchInfo{}, fmt.Errorf("invalid URI scheme")
 	}
 
 	u, err := url.Parse(uri)
 	if err != nil {
-		return nil, err
+		return nil, BatchInfo{}, err
 	}
 
 	dataParam := u.Query().Get("data")
 	if dataParam == "" {
-		return nil, fmt.Errorf("missing 'data' parameter")
+		return nil, BatchInfo{}, fmt.Errorf("missing 'data' parameter")
 	}
 
 	protoData, err := base64.StdEncoding.DecodeString(dataParam)
 	if err != nil {
-		return nil, fmt.Errorf("base64 decode error: %w", err)
+		return nil, BatchInfo{}, fmt.Errorf("base64 decode error: %w", err)
 	}
 
 	return parsePayload(protoData)
 }
 
-func parsePayload(data []byte) ([]*OtpParameters, error) {
+func parsePayload(data []byte) ([]*OtpParameters, BatchInfo, error) {
 	d := &decoder{data: data}
 	var accounts []*OtpParameters
+	info := BatchInfo{BatchSize: 1}
 
 	for d.pos < len(d.data) {
 		tag, err := d.varint()
@@ -100,28 +130,57 @@ func parsePayload(data []byte) ([]*OtpParameters, error) {
 		switch field {
 		case 1: // otp_parameters (repeated)
 			if wire != wireLen {
-				return nil, fmt.Errorf("invalid wire type for otp_parameters")
+				return nil, info, fmt.Errorf("invalid wire type for otp_parameters")
 			}
 			paramBytes, err := d.bytes()
 			if err != nil {
-				return nil, err
+				return nil, info, err
 			}
 			param, err := parseOtpParameters(paramBytes)
 			if err != nil {
-				return nil, err
+				return nil, info, err
 			}
 			accounts = append(accounts, param)
 
 		case 2, 3, 4, 5: // version, batch_size, batch_index, batch_id
-			if wire == wireVarint {
-				_, _ = d.varint() // Skip these fields
+			if wire != wireVarint {
+				return nil, info, fmt.Errorf("invalid wire type for field %d", field)
+			}
+			val, err := d.varint()
+			if err != nil {
+				return nil, info, err
+			}
+			switch field {
+			case 2:
+				info.Version = int(val)
+			case 3:
+				if val > maxBatchSize {
+					return nil, info, fmt.Errorf("invalid batch size: %d", val)
+				}
+				info.BatchSize = int(val)
+			case 4:
+				if val >= maxBatchSize {
+					return nil, info, fmt.Errorf("invalid batch index: %d", val)
+				}
+				info.BatchIndex = int(val)
+				info.explicitIndex = true
+			case 5:
+				// batch_id 为 int32，负数按 64 位补码编码
+				info.BatchID = int32(int64(val))
 			}
 		default:
-			return nil, fmt.Errorf("unknown field: %d", field)
+			return nil, info, fmt.Errorf("unknown field: %d", field)
 		}
 	}
 
-	return accounts, nil
+	if info.BatchSize < 1 {
+		info.BatchSize = 1
+	}
+	if info.BatchIndex < 0 || info.BatchIndex >= info.BatchSize {
+		return nil, info, fmt.Errorf("invalid batch index %d of %d", info.BatchIndex, info.BatchSize)
+	}
+
+	return accounts, info, nil
 }
 
 func parseOtpParameters(data []byte) (*OtpParameters, error) {
@@ -189,10 +248,68 @@ func parseOtpParameters(data []byte) (*OtpParameters, error) {
 	return param, nil
 }
 
+// DefaultAccountsPerBatch 每个迁移二维码默认容纳的账户数（与 Google Authenticator 一致）
+const DefaultAccountsPerBatch = 10
+
 // GenerateMigrationURISimple generates otpauth-migration:// URI
+// 所有账户写入同一个二维码
 func GenerateMigrationURISimple(accounts []*OtpParameters) (string, error) {
+	batchID, err := newBatchID()
+	if err != nil {
+		return "", err
+	}
+	return buildMigrationURI(accounts, BatchInfo{
+		Version:   1,
+		BatchSize: 1,
+		BatchID:   batchID,
+	}), nil
+}
+
+// GenerateMigrationURIs 将账户拆分为多页迁移码
+// 每页最多 perBatch 个账户，所有页共享同一个随机 batch_id，按页序返回
+func GenerateMigrationURIs(accounts []*OtpParameters, perBatch int) ([]string, error) {
+	if len(accounts) == 0 {
+		return nil, fmt.Errorf("no accounts to export")
+	}
+	if perBatch <= 0 {
+		perBatch = DefaultAccountsPerBatch
+	}
+
+	batchID, err := newBatchID()
+	if err != nil {
+		return nil, err
+	}
+
+	batchSize := (len(accounts) + perBatch - 1) / perBatch
+	uris := make([]string, 0, batchSize)
+	for i := 0; i < batchSize; i++ {
+		end := (i + 1) * perBatch
+		if end > len(accounts) {
+			end = len(accounts)
+		}
+		uris = append(uris, buildMigrationURI(accounts[i*perBatch:end], BatchInfo{
+			Version:    1,
+			BatchSize:  batchSize,
+			BatchIndex: i,
+			BatchID:    batchID,
+		}))
+	}
+
+	return uris, nil
+}
+
+// newBatchID 生成随机的 batch_id
+func newBatchID() (int32, error) {
+	var b [4]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return 0, fmt.Errorf("failed to generate batch id: %w", err)
+	}
+	return int32(binary.BigEndian.Uint32(b[:])), nil
+}
+
+func buildMigrationURI(accounts []*OtpParameters, info BatchInfo) string {
 	// Encode payload
-	payloadData := encodePayload(accounts)
+	payloadData := encodePayload(accounts, info)
 
 	// Base64 encode
 	encoded := base64.StdEncoding.EncodeToString(payloadData)
@@ -200,10 +317,10 @@ func GenerateMigrationURISimple(accounts []*OtpParameters) (string, error) {
 	// URL encode the base64 string (important!)
 	encodedURL := url.QueryEscape(encoded)
 
-	return fmt.Sprintf("otpauth-migration://offline?data=%s", encodedURL), nil
+	return fmt.Sprintf("otpauth-migration://offline?data=%s", encodedURL)
 }
 
-func encodePayload(accounts []*OtpParameters) []byte {
+func encodePayload(accounts []*OtpParameters, info BatchInfo) []byte {
 	var buf []byte
 
 	for _, acc := range accounts {
@@ -213,21 +330,23 @@ func encodePayload(accounts []*OtpParameters) []byte {
 		buf = appendBytes(buf, paramData)
 	}
 
-	// version = 1 (field 2)
+	// version (field 2)
 	buf = appendTag(buf, 2, wireVarint)
-	buf = appendVarint(buf, 1)
+	buf = appendVarint(buf, uint64(info.Version))
 
-	// batch_size (field 3)
+	// batch_size (field 3) - 二维码总页数
 	buf = appendTag(buf, 3, wireVarint)
-	buf = appendVarint(buf, uint64(len(accounts)))
+	buf = appendVarint(buf, uint64(info.BatchSize))
 
-	// batch_index = 0 (field 4) - 从0开始
-	buf = appendTag(buf, 4, wireVarint)
-	buf = appendVarint(buf, 0)
+	// batch_index (field 4) - 从0开始，与 Google Authenticator 一致省略值为 0 的字段
+	if info.BatchIndex != 0 {
+		buf = appendTag(buf, 4, wireVarint)
+		buf = appendVarint(buf, uint64(info.BatchIndex))
+	}
 
-	// batch_id (field 5) - 使用时间戳作为唯一ID
+	// batch_id (field 5) - int32，负数按 64 位补码编码
 	buf = appendTag(buf, 5, wireVarint)
-	buf = appendVarint(buf, uint64(time.Now().UnixNano()%1000000))
+	buf = appendVarint(buf, uint64(int64(info.BatchID)))
 
 	return buf
 }
@@ -0,0 +1,130 @@
+package migration
+
+import (
+	"bytes"
+	"crypto/pbkdf2"
+	"crypto/sha1"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+
+	"google-authenticator/internal/otp"
+)
+
+// Steam Desktop Authenticator 的 .maFile 为 JSON，shared_secret 为 base64 编码。
+// 启用加密后 .maFile 内容为 base64 密文，盐值和 IV 保存在同目录的 manifest.json 中：
+// 密钥 = PBKDF2-SHA1(passkey, salt, 50000, 32)，AES-256-CBC + PKCS#7。
+
+const (
+	steamPBKDF2Iterations = 50000
+	steamKeyLen           = 32
+)
+
+type steamMaFile struct {
+	SharedSecret   string `json:"shared_secret"`
+	AccountName    string `json:"account_name"`
+	RevocationCode string `json:"revocation_code"`
+}
+
+type steamManifest struct {
+	Encrypted bool                 `json:"encrypted"`
+	Entries   []steamManifestEntry `json:"entries"`
+}
+
+type steamManifestEntry struct {
+	EncryptionIV   string `json:"encryption_iv"`
+	EncryptionSalt string `json:"encryption_salt"`
+	Filename       string `json:"filename"`
+}
+
+// ParseSteamMaFile 解析未加密的 .maFile，加密文件使用 ParseSteamMaFileWithManifest
+func ParseSteamMaFile(data []byte) ([]otp.Account, []string, error) {
+	return ParseSteamMaFileWithManifest(data, "", nil, "")
+}
+
+// ParseSteamMaFileWithManifest 解析 .maFile，加密文件需要同目录的 manifest.json 和 passkey
+// filename 为 .maFile 的文件名，用于在 manifest 中查找盐值和 IV
+func ParseSteamMaFileWithManifest(data []byte, filename string, manifest []byte, passkey string) ([]otp.Account, []string, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] != '{' {
+		var err error
+		data, err = decryptSteamMaFile(data, filename, manifest, passkey)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	var file steamMaFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, nil, fmt.Errorf("invalid maFile: %w", err)
+	}
+	if file.SharedSecret == "" {
+		return nil, nil, fmt.Errorf("maFile has no shared_secret")
+	}
+
+	secret, err := base64.StdEncoding.DecodeString(file.SharedSecret)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid shared_secret: %w", err)
+	}
+	acc, err := newSteamAccount(file.AccountName, "Steam", base32.StdEncoding.EncodeToString(secret))
+	if err != nil {
+		return nil, nil, err
+	}
+	if file.RevocationCode != "" {
+		acc.Note = "恢复码: " + file.RevocationCode
+	}
+	return []otp.Account{acc}, nil, nil
+}
+
+// decryptSteamMaFile 使用 manifest 中对应条目的盐值和 IV 解密 .maFile
+func decryptSteamMaFile(data []byte, filename string, manifest []byte, passkey string) ([]byte, error) {
+	if manifest == nil {
+		return nil, fmt.Errorf("encrypted maFile requires manifest.json in the same folder")
+	}
+	var m steamManifest
+	if err := json.Unmarshal(manifest, &m); err != nil {
+		return nil, fmt.Errorf("invalid manifest.json: %w", err)
+	}
+
+	var entry *steamManifestEntry
+	for i := range m.Entries {
+		if filepath.Base(m.Entries[i].Filename) == filepath.Base(filename) {
+			entry = &m.Entries[i]
+			break
+		}
+	}
+	if entry == nil {
+		return nil, fmt.Errorf("%s not found in manifest.json", filepath.Base(filename))
+	}
+	if passkey == "" {
+		return nil, ErrPasswordRequired
+	}
+
+	salt, err := base64.StdEncoding.DecodeString(entry.EncryptionSalt)
+	if err != nil {
+		return nil, fmt.Errorf("invalid encryption salt: %w", err)
+	}
+	iv, err := base64.StdEncoding.DecodeString(entry.EncryptionIV)
+	if err != nil {
+		return nil, fmt.Errorf("invalid encryption iv: %w", err)
+	}
+	ciphertext, err := base64.StdEncoding.DecodeString(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("invalid maFile: %w", err)
+	}
+
+	key, err := pbkdf2.Key(sha1.New, passkey, salt, steamPBKDF2Iterations, steamKeyLen)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive key: %w", err)
+	}
+	plaintext, err := openCBC(key, iv, ciphertext)
+	if err != nil {
+		return nil, err
+	}
+	if !json.Valid(plaintext) {
+		return nil, ErrWrongPassword
+	}
+	return plaintext, nil
+}
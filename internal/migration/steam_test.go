@@ -0,0 +1,42 @@
+package migration
+
+import (
+	"errors"
+	"testing"
+
+	"google-authenticator/internal/otp"
+)
+
+const steamTestMaFile = "76561197960287930.maFile"
+
+func TestParseSteamMaFileEncrypted(t *testing.T) {
+	data := readTestdata(t, "steam/"+steamTestMaFile)
+	manifest := readTestdata(t, "steam/manifest.json")
+
+	accounts, warnings, err := ParseSteamMaFileWithManifest(data, steamTestMaFile, manifest, "steam-test")
+	if err != nil {
+		t.Fatalf("ParseSteamMaFileWithManifest: %v", err)
+	}
+	if len(warnings) != 0 {
+		t.Errorf("unexpected warnings: %v", warnings)
+	}
+	// shared_secret 为 base64，导入后转换为 base32，恢复码保存在备注中
+	checkAccounts(t, accounts,
+		otp.Account{Name: "gaben", Issuer: "Steam", Secret: "KRUGS4ZAONUG65LMMQQGEZJAMEQHGZLDOJSXI===", Algorithm: "SHA1", Digits: otp.SteamDigits, Type: otp.TypeSteam, Period: 30, Note: "恢复码: R12345"},
+	)
+}
+
+func TestParseSteamMaFileWrongPasskey(t *testing.T) {
+	data := readTestdata(t, "steam/"+steamTestMaFile)
+	manifest := readTestdata(t, "steam/manifest.json")
+
+	if _, _, err := ParseSteamMaFileWithManifest(data, steamTestMaFile, manifest, "wrong"); !errors.Is(err, ErrWrongPassword) {
+		t.Errorf("wrong passkey: err = %v, want ErrWrongPassword", err)
+	}
+	if _, _, err := ParseSteamMaFileWithManifest(data, steamTestMaFile, manifest, ""); !errors.Is(err, ErrPasswordRequired) {
+		t.Errorf("empty passkey: err = %v, want ErrPasswordRequired", err)
+	}
+	if _, _, err := ParseSteamMaFile(data); err == nil {
+		t.Error("missing manifest: expected error")
+	}
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"bytes"
+	"crypto/pbkdf2"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+)
+
+// andOTP 加密备份，参见 andOTP 的 EncryptionHelper / BackupHelper：
+//   - 新格式（0.7.0 起）：iterations(4 字节大端) + salt(12) + iv(12) + AES-256-GCM 密文，
+//     密钥为 PBKDF2-HMAC-SHA1(password, salt, iterations)，iterations 在 140000~160000 间随机
+//   - 旧格式：iv(12) + AES-256-GCM 密文，密钥为 SHA-256(password)
+func init() { generators["andotp"] = genAndOTP }
+
+const andOTPPassword = "andotp-test"
+
+const andOTPEntries = `[` +
+	`{"secret":"JBSWY3DPEHPK3PXP","issuer":"Example","label":"alice@example.com","digits":6,"type":"TOTP","algorithm":"SHA1","thumbnail":"Default","last_used":1700000000000,"used_frequency":3,"period":30,"tags":["Work","Mail"]},` +
+	`{"secret":"GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ","issuer":"Bank","label":"bob","digits":8,"type":"HOTP","algorithm":"SHA256","thumbnail":"Default","last_used":0,"used_frequency":0,"counter":5,"tags":[]},` +
+	`{"secret":"KRUGS4ZAONUG65LMMQQGEZJAMEQHGZLDOJSXI","issuer":"Steam","label":"gaben","digits":5,"type":"STEAM","algorithm":"SHA1","thumbnail":"Steam","last_used":0,"used_frequency":0,"period":30,"tags":[]},` +
+	`{"secret":"MFRGGZDFMZTWQ2LK","label":"GitHub - dave","digits":6,"type":"TOTP","algorithm":"SHA512","thumbnail":"Default","period":60,"tags":[]}` +
+	`]`
+
+func genAndOTP(dir string) error {
+	iterations := 140000
+	salt := randomBytes(12)
+	nonce := randomBytes(12)
+	key, err := pbkdf2.Key(sha1.New, andOTPPassword, salt, iterations, 32)
+	if err != nil {
+		return err
+	}
+	ciphertext, err := sealGCM(key, nonce, []byte(andOTPEntries))
+	if err != nil {
+		return err
+	}
+	var out bytes.Buffer
+	binary.Write(&out, binary.BigEndian, uint32(iterations))
+	out.Write(salt)
+	out.Write(nonce)
+	out.Write(ciphertext)
+	if err := os.WriteFile(filepath.Join(dir, "andotp_encrypted.json.aes"), out.Bytes(), 0o644); err != nil {
+		return err
+	}
+
+	legacyKey := sha256.Sum256([]byte(andOTPPassword))
+	nonce = randomBytes(12)
+	ciphertext, err = sealGCM(legacyKey[:], nonce, []byte(andOTPEntries))
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, "andotp_legacy.json.aes"), append(nonce, ciphertext...), 0o644)
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"bytes"
+	"crypto/pbkdf2"
+	"crypto/sha1"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// Authenticator Pro 加密备份，参见 Authenticator Pro 的 Backup.cs：
+//   - 新格式（1.20 起）："AUTHENTICATORPRO" + salt(16) + iv(12) + AES-256-GCM 密文，
+//     密钥为 Argon2id(t=3, m=64 MiB, p=4)
+//   - 旧格式："AuthenticatorPro" + salt(20) + iv(16) + AES-256-CBC 密文，密钥为 PBKDF2-SHA1(64000)
+func init() { generators["authpro"] = genAuthPro }
+
+const authProPassword = "authpro-test"
+
+// 类型：1 HOTP，2 TOTP，3 mOTP，4 Steam，5 Yandex；算法：0 SHA1，1 SHA256，2 SHA512
+const authProBackup = `{"Authenticators":[` +
+	`{"Type":2,"Icon":"example","Issuer":"Example","Username":"alice@example.com","Secret":"JBSWY3DPEHPK3PXP","Pin":null,"Algorithm":0,"Digits":6,"Period":30,"Counter":0,"Ranking":0,"CopyCount":0},` +
+	`{"Type":1,"Icon":null,"Issuer":"Bank","Username":"bob","Secret":"GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ","Pin":null,"Algorithm":1,"Digits":8,"Period":30,"Counter":5,"Ranking":1,"CopyCount":0},` +
+	`{"Type":4,"Icon":"steam","Issuer":"Steam","Username":"gaben","Secret":"KRUGS4ZAONUG65LMMQQGEZJAMEQHGZLDOJSXI","Pin":null,"Algorithm":0,"Digits":5,"Period":30,"Counter":0,"Ranking":2,"CopyCount":0},` +
+	`{"Type":5,"Icon":"yandex","Issuer":"Yandex","Username":"carol","Secret":"GAYTEMZUGU3DOOBZMFRGGZDFMY","Pin":"5239","Algorithm":1,"Digits":8,"Period":30,"Counter":0,"Ranking":3,"CopyCount":0},` +
+	`{"Type":3,"Icon":null,"Issuer":"VPN","Username":"frank","Secret":"e3152afee62599c8","Pin":"1234","Algorithm":0,"Digits":6,"Period":10,"Counter":0,"Ranking":4,"CopyCount":0}],` +
+	`"Categories":[{"Id":"6c3f1a2b","Name":"Work","Ranking":0}],` +
+	`"AuthenticatorCategories":[{"CategoryId":"6c3f1a2b","AuthenticatorSecret":"JBSWY3DPEHPK3PXP","Ranking":0}],"CustomIcons":[],"IconPacks":[]}`
+
+func genAuthPro(dir string) error {
+	salt := randomBytes(16)
+	nonce := randomBytes(12)
+	key := argon2.IDKey([]byte(authProPassword), salt, 3, 64*1024, 4, 32)
+	ciphertext, err := sealGCM(key, nonce, []byte(authProBackup))
+	if err != nil {
+		return err
+	}
+	var out bytes.Buffer
+	out.WriteString("AUTHENTICATORPRO")
+	out.Write(salt)
+	out.Write(nonce)
+	out.Write(ciphertext)
+	if err := os.WriteFile(filepath.Join(dir, "authpro_encrypted.authpro"), out.Bytes(), 0o644); err != nil {
+		return err
+	}
+
+	salt = randomBytes(20)
+	iv := randomBytes(16)
+	key, err = pbkdf2.Key(sha1.New, authProPassword, salt, 64000, 32)
+	if err != nil {
+		return err
+	}
+	ciphertext, err = sealCBC(key, iv, []byte(authProBackup))
+	if err != nil {
+		return err
+	}
+	out.Reset()
+	out.WriteString("AuthenticatorPro")
+	out.Write(salt)
+	out.Write(iv)
+	out.Write(ciphertext)
+	return os.WriteFile(filepath.Join(dir, "authpro_legacy.authpro"), out.Bytes(), 0o644)
+}
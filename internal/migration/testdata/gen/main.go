@@ -0,0 +1,84 @@
+// gen 生成 internal/migration/testdata 中的加密备份样本
+//
+// 每种格式按对应应用公开的文件格式说明独立实现加密（不调用 migration 包的解密代码），
+// 盐值与 IV 随机生成，因此每次运行得到的文件不同，但都能用 README.md 中记录的密码解密。
+//
+//	go run ./internal/migration/testdata/gen          # 重新生成全部样本
+//	go run ./internal/migration/testdata/gen aegis    # 只生成指定格式
+package main
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+)
+
+// generators 按格式名注册的样本生成函数，dir 为 testdata 目录
+var generators = map[string]func(dir string) error{}
+
+func main() {
+	_, file, _, _ := runtime.Caller(0)
+	dir := filepath.Dir(filepath.Dir(file))
+
+	names := os.Args[1:]
+	if len(names) == 0 {
+		for name := range generators {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+	}
+
+	for _, name := range names {
+		gen, ok := generators[name]
+		if !ok {
+			fmt.Fprintf(os.Stderr, "unknown format: %s\n", name)
+			os.Exit(2)
+		}
+		if err := gen(dir); err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", name, err)
+			os.Exit(1)
+		}
+		fmt.Println("generated", name)
+	}
+}
+
+// randomBytes 返回 n 字节随机数
+func randomBytes(n int) []byte {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return b
+}
+
+// sealGCM 使用 AES-GCM 加密，tag 附在密文末尾
+func sealGCM(key, nonce, plaintext []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCMWithNonceSize(block, len(nonce))
+	if err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nil, nonce, plaintext, nil), nil
+}
+
+// sealCBC 使用 AES-CBC 加密并添加 PKCS#7 填充
+func sealCBC(key, iv, plaintext []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	padding := block.BlockSize() - len(plaintext)%block.BlockSize()
+	padded := append(append([]byte{}, plaintext...), bytes.Repeat([]byte{byte(padding)}, padding)...)
+	out := make([]byte, len(padded))
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(out, padded)
+	return out, nil
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"crypto/pbkdf2"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"path/filepath"
+)
+
+// 2FAS 加密备份（schemaVersion 4），参见 2FAS Android 的 BackupEncryption：
+// servicesEncrypted 与 reference 均为 "密文+tag:salt:iv"（base64），
+// 密钥为 PBKDF2-HMAC-SHA256(password, salt(256), 10000)，AES-256-GCM；加密备份的 services 为空数组
+func init() { generators["2fas"] = genTwoFAS }
+
+const twoFASPassword = "2fas-test"
+
+// twoFASReference 2FAS 用于校验密码的固定明文
+const twoFASReference = "tRViSsLKzd86Hprh4ceC2OP7xazn4rrt4xhfEUbOjxLX8Rc3mkISXE0lWbmnWfggogbBJhtYgpK6fMl1D6mtsy92R3HkdGfwuXbzLebqVFJsR7IZ2w58t938iymwG4824igYy1wi6n2WDpO1Q1P69zwJGs2F5a1qP4MyIiDSD7NCV2OvidXQCBnDlGfmz0f1BQySRkkt4ryiJeCjD2o4QsveJ9uDBUn8ELyOrESv5R5DMDkD4iAF8TXU7KyoJujd"
+
+const twoFASServices = `[` +
+	`{"name":"Example","secret":"JBSWY3DPEHPK3PXP","updatedAt":1700000000000,"otp":{"label":"Example:alice@example.com","account":"alice@example.com","issuer":"Example","digits":6,"period":30,"algorithm":"SHA1","tokenType":"TOTP","source":"Link"},"order":{"position":0},"groupId":"A1B2C3D4-0000-4000-8000-000000000001","icon":{"selected":"Label","label":{"text":"EX","backgroundColor":"Blue"}}},` +
+	`{"name":"Bank","secret":"GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ","updatedAt":1700000000000,"otp":{"label":"bob","account":"bob","issuer":"Bank","digits":8,"period":30,"algorithm":"SHA256","counter":5,"tokenType":"HOTP","source":"Manual"},"order":{"position":1}},` +
+	`{"name":"Steam","secret":"KRUGS4ZAONUG65LMMQQGEZJAMEQHGZLDOJSXI","updatedAt":1700000000000,"otp":{"label":"Steam:gaben","account":"gaben","digits":5,"period":30,"algorithm":"SHA1","tokenType":"STEAM","source":"Manual"},"order":{"position":2}},` +
+	`{"name":"Forum","secret":"MFRGGZDFMZTWQ2LK","updatedAt":1700000000000,"otp":{"label":"Forum:erin","digits":7,"period":45,"algorithm":"SHA512","tokenType":"TOTP","source":"Manual"},"order":{"position":3}}` +
+	`]`
+
+func genTwoFAS(dir string) error {
+	seal := func(plaintext string) (string, error) {
+		salt := randomBytes(256)
+		nonce := randomBytes(12)
+		key, err := pbkdf2.Key(sha256.New, twoFASPassword, salt, 10000, 32)
+		if err != nil {
+			return "", err
+		}
+		ciphertext, err := sealGCM(key, nonce, []byte(plaintext))
+		if err != nil {
+			return "", err
+		}
+		enc := base64.StdEncoding.EncodeToString
+		return enc(ciphertext) + ":" + enc(salt) + ":" + enc(nonce), nil
+	}
+
+	services, err := seal(twoFASServices)
+	if err != nil {
+		return err
+	}
+	reference, err := seal(twoFASReference)
+	if err != nil {
+		return err
+	}
+
+	backup := map[string]any{
+		"services":          []any{},
+		"groups":            []any{map[string]any{"id": "A1B2C3D4-0000-4000-8000-000000000001", "name": "Work", "isExpanded": true}},
+		"updatedAt":         1700000000000,
+		"schemaVersion":     4,
+		"appVersionCode":    5000012,
+		"appVersionName":    "5.0.12",
+		"appOrigin":         "android",
+		"servicesEncrypted": services,
+		"reference":         reference,
+	}
+	out, err := json.MarshalIndent(backup, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, "twofas_encrypted.2fas"), out, 0o644)
+}
@@ -0,0 +1,37 @@
+package migration
+
+import (
+	"errors"
+	"testing"
+
+	"google-authenticator/internal/otp"
+)
+
+func TestParseTwoFASBackupEncrypted(t *testing.T) {
+	accounts, warnings, err := ParseTwoFASBackup(readTestdata(t, "twofas_encrypted.2fas"), "2fas-test")
+	if err != nil {
+		t.Fatalf("ParseTwoFASBackup: %v", err)
+	}
+	if len(warnings) != 0 {
+		t.Errorf("unexpected warnings: %v", warnings)
+	}
+	checkAccounts(t, accounts,
+		otp.Account{Name: "alice@example.com", Issuer: "Example", Secret: "JBSWY3DPEHPK3PXP", Algorithm: "SHA1", Digits: 6, Type: "TOTP", Period: 30, Group: "Work"},
+		otp.Account{Name: "bob", Issuer: "Bank", Secret: "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ", Algorithm: "SHA256", Digits: 8, Type: "HOTP", Period: 30, Counter: 5},
+		// 缺少 issuer 时使用服务名
+		otp.Account{Name: "gaben", Issuer: "Steam", Secret: "KRUGS4ZAONUG65LMMQQGEZJAMEQHGZLDOJSXI===", Algorithm: "SHA1", Digits: otp.SteamDigits, Type: otp.TypeSteam, Period: 30},
+		// 缺少 account 时从 label 中取冒号后的部分
+		otp.Account{Name: "erin", Issuer: "Forum", Secret: "MFRGGZDFMZTWQ2LK", Algorithm: "SHA512", Digits: 7, Type: "TOTP", Period: 45},
+	)
+}
+
+func TestParseTwoFASBackupWrongPassword(t *testing.T) {
+	data := readTestdata(t, "twofas_encrypted.2fas")
+
+	if _, _, err := ParseTwoFASBackup(data, "wrong"); !errors.Is(err, ErrWrongPassword) {
+		t.Errorf("wrong password: err = %v, want ErrWrongPassword", err)
+	}
+	if _, _, err := ParseTwoFASBackup(data, ""); !errors.Is(err, ErrPasswordRequired) {
+		t.Errorf("empty password: err = %v, want ErrPasswordRequired", err)
+	}
+}
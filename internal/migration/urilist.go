@@ -0,0 +1,89 @@
+package migration
+
+import (
+	"bufio"
+	"fmt"
+	"strings"
+
+	"google-authenticator/internal/otp"
+)
+
+// LineError 文本列表中无法解析的行
+type LineError struct {
+	Line  int    `json:"line"` // 行号，从 1 开始
+	Text  string `json:"text"`
+	Error string `json:"error"`
+}
+
+// ParseURIList 逐行解析 otpauth:// 与 otpauth-migration:// URI 列表（如 Ente Auth 的明文导出）
+// 空行与以 # 开头的注释行会被忽略，返回账户、告警及出错的行
+func ParseURIList(text string) ([]otp.Account, []string, []LineError) {
+	var accounts []otp.Account
+	var warnings []string
+	var lineErrors []LineError
+
+	scanner := bufio.NewScanner(strings.NewReader(text))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		lower := strings.ToLower(line)
+		switch {
+		case strings.HasPrefix(lower, "otpauth-migration://"):
+			params, _, err := ParseMigrationURISimple(line)
+			if err != nil {
+				lineErrors = append(lineErrors, LineError{Line: lineNo, Text: line, Error: err.Error()})
+				continue
+			}
+			for _, p := range params {
+				accounts = append(accounts, p.Account())
+			}
+
+		case strings.HasPrefix(lower, "otpauth://"), strings.HasPrefix(lower, "steam://"):
+			key, err := ParseKeyURI(line)
+			if err != nil {
+				lineErrors = append(lineErrors, LineError{Line: lineNo, Text: line, Error: err.Error()})
+				continue
+			}
+			for _, w := range key.Warnings {
+				warnings = append(warnings, fmt.Sprintf("第 %d 行: %s", lineNo, w))
+			}
+			accounts = append(accounts, key.Account())
+
+		default:
+			lineErrors = append(lineErrors, LineError{Line: lineNo, Text: line, Error: "unsupported URI scheme"})
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		lineErrors = append(lineErrors, LineError{Line: lineNo + 1, Error: err.Error()})
+	}
+
+	return accounts, warnings, lineErrors
+}
+
+// ParseURIListFile 按备份导入接口解析 URI 列表文件，出错的行转为告警
+func ParseURIListFile(data []byte, _ string) ([]otp.Account, []string, error) {
+	accounts, warnings, lineErrors := ParseURIList(string(data))
+	for _, e := range lineErrors {
+		warnings = append(warnings, fmt.Sprintf("第 %d 行: 已跳过 (%s)", e.Line, e.Error))
+	}
+	if len(accounts) == 0 && len(lineErrors) > 0 {
+		return nil, warnings, fmt.Errorf("no valid otpauth URI found")
+	}
+	return accounts, warnings, nil
+}
+
+// GenerateURIList 生成每行一个 otpauth:// URI 的文本，可由 ParseURIList 重新导入
+func GenerateURIList(accounts []otp.Account) []byte {
+	var b strings.Builder
+	for _, acc := range accounts {
+		b.WriteString(BuildOTPAuthURI(acc))
+		b.WriteString("\n")
+	}
+	return []byte(b.String())
+}
@@ -0,0 +1,143 @@
+package ntp
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+)
+
+// SNTP (RFC 4330) 客户端，用于测量本机时钟与时间服务器的偏差
+const (
+	DefaultServer  = "pool.ntp.org"
+	DefaultPort    = "123"
+	DefaultTimeout = 5 * time.Second
+
+	packetLen = 48
+
+	// NTP 时间戳从 1900-01-01 起算
+	ntpEpochOffset = 2208988800
+
+	modeClient = 3
+	modeServer = 4
+	version    = 4
+
+	maxStratum = 15
+
+	// MaxOffset 可接受的最大偏差，本机时钟误差通常在数小时以内，
+	// 更大的偏差多为服务器应答异常，不应据此校正
+	MaxOffset = 12 * time.Hour
+)
+
+// Result 一次查询的结果
+type Result struct {
+	Server string        `json:"server"` // 实际查询的地址
+	Time   time.Time     `json:"time"`   // 服务器时间（收到应答时）
+	Offset time.Duration `json:"offset"` // 服务器时间减去本机时间
+	RTT    time.Duration `json:"rtt"`    // 往返延迟
+}
+
+// ErrKissOfDeath 服务器拒绝服务（stratum 为 0）
+var ErrKissOfDeath = errors.New("NTP server sent kiss-o'-death")
+
+// Query 向 server 发送一次 SNTP 请求，server 为主机名或 host:port，默认端口 123
+func Query(server string, timeout time.Duration) (*Result, error) {
+	if server == "" {
+		server = DefaultServer
+	}
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	addr := server
+	if _, _, err := net.SplitHostPort(server); err != nil {
+		addr = net.JoinHostPort(server, DefaultPort)
+	}
+
+	conn, err := net.DialTimeout("udp", addr, timeout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s: %w", addr, err)
+	}
+	defer conn.Close()
+	if err := conn.SetDeadline(time.Now().Add(timeout)); err != nil {
+		return nil, err
+	}
+
+	// 请求：LI = 0，版本 4，客户端模式，发送时间写入 Transmit Timestamp
+	req := make([]byte, packetLen)
+	req[0] = version<<3 | modeClient
+	start := time.Now()
+	binary.BigEndian.PutUint64(req[40:], toNTPTime(start))
+	if _, err := conn.Write(req); err != nil {
+		return nil, fmt.Errorf("failed to send NTP request: %w", err)
+	}
+
+	resp := make([]byte, packetLen)
+	n, err := conn.Read(resp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read NTP response: %w", err)
+	}
+	// 使用单调时钟计算接收时间，避免查询期间系统时间被调整
+	end := start.Add(time.Since(start))
+
+	if n < packetLen {
+		return nil, fmt.Errorf("short NTP response: %d bytes", n)
+	}
+	if mode := resp[0] & 0x07; mode != modeServer {
+		return nil, fmt.Errorf("unexpected NTP mode: %d", mode)
+	}
+	if resp[0]>>6 == 3 {
+		return nil, fmt.Errorf("NTP server clock is not synchronized")
+	}
+	if resp[1] == 0 {
+		return nil, ErrKissOfDeath
+	}
+	if resp[1] > maxStratum {
+		return nil, fmt.Errorf("invalid NTP stratum: %d", resp[1])
+	}
+	// Transmit Timestamp 为 0 的应答无效（RFC 4330 第 5 节）
+	if binary.BigEndian.Uint64(resp[40:]) == 0 {
+		return nil, fmt.Errorf("NTP response has no transmit timestamp")
+	}
+	// Originate Timestamp 必须与请求的发送时间一致
+	if !bytes.Equal(resp[24:32], req[40:48]) {
+		return nil, fmt.Errorf("NTP response does not match request")
+	}
+
+	t1 := start
+	t2 := fromNTPTime(binary.BigEndian.Uint64(resp[32:]))
+	t3 := fromNTPTime(binary.BigEndian.Uint64(resp[40:]))
+	t4 := end
+
+	offset := (t2.Sub(t1) + t3.Sub(t4)) / 2
+	rtt := t4.Sub(t1) - t3.Sub(t2)
+	if rtt < 0 {
+		rtt = 0
+	}
+	if offset > MaxOffset || offset < -MaxOffset {
+		return nil, fmt.Errorf("NTP offset %v exceeds %v", offset.Round(time.Second), MaxOffset)
+	}
+
+	return &Result{
+		Server: addr,
+		Time:   t4.Add(offset),
+		Offset: offset,
+		RTT:    rtt,
+	}, nil
+}
+
+// toNTPTime 转换为 64 位 NTP 时间戳（32 位秒 + 32 位小数）
+func toNTPTime(t time.Time) uint64 {
+	nanos := uint64(t.UnixNano()) + ntpEpochOffset*uint64(time.Second)
+	sec := nanos / uint64(time.Second)
+	frac := (nanos % uint64(time.Second)) << 32 / uint64(time.Second)
+	return sec<<32 | frac
+}
+
+// fromNTPTime 将 64 位 NTP 时间戳转换为 time.Time
+func fromNTPTime(v uint64) time.Time {
+	sec := int64(v>>32) - ntpEpochOffset
+	nanos := int64((v & 0xffffffff) * uint64(time.Second) >> 32)
+	return time.Unix(sec, nanos)
+}
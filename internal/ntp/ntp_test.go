@@ -0,0 +1,125 @@
+package ntp
+
+import (
+	"encoding/binary"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// serveOnce 在本机回环地址上启动只应答一次的 SNTP 服务器，reply 修改应答内容
+func serveOnce(t *testing.T, skew time.Duration, reply func(resp []byte)) string {
+	t.Helper()
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	go func() {
+		req := make([]byte, packetLen)
+		n, addr, err := conn.ReadFrom(req)
+		if err != nil || n < packetLen {
+			return
+		}
+		now := time.Now().Add(skew)
+		resp := make([]byte, packetLen)
+		resp[0] = version<<3 | modeServer
+		resp[1] = 2
+		copy(resp[24:32], req[40:48])
+		binary.BigEndian.PutUint64(resp[32:], toNTPTime(now))
+		binary.BigEndian.PutUint64(resp[40:], toNTPTime(now))
+		if reply != nil {
+			reply(resp)
+		}
+		conn.WriteTo(resp, addr)
+	}()
+
+	return conn.LocalAddr().String()
+}
+
+func TestQueryOffset(t *testing.T) {
+	addr := serveOnce(t, 5*time.Second, nil)
+
+	result, err := Query(addr, time.Second)
+	if err != nil {
+		t.Fatalf("Query: %v", err)
+	}
+	if result.Server != addr {
+		t.Errorf("Server = %s, want %s", result.Server, addr)
+	}
+	if d := result.Offset - 5*time.Second; d < -100*time.Millisecond || d > 100*time.Millisecond {
+		t.Errorf("Offset = %v, want about 5s", result.Offset)
+	}
+	if result.RTT < 0 || result.RTT > time.Second {
+		t.Errorf("RTT = %v", result.RTT)
+	}
+}
+
+func TestQueryRejectsInvalidReply(t *testing.T) {
+	tests := []struct {
+		name  string
+		skew  time.Duration
+		reply func(resp []byte)
+		err   string
+	}{
+		{
+			name:  "kiss-o'-death",
+			reply: func(resp []byte) { resp[1] = 0 },
+			err:   "kiss-o'-death",
+		},
+		{
+			name:  "stratum 16",
+			reply: func(resp []byte) { resp[1] = 16 },
+			err:   "stratum",
+		},
+		{
+			name:  "unsynchronized",
+			reply: func(resp []byte) { resp[0] |= 3 << 6 },
+			err:   "not synchronized",
+		},
+		{
+			name:  "client mode",
+			reply: func(resp []byte) { resp[0] = version<<3 | modeClient },
+			err:   "mode",
+		},
+		{
+			name:  "zero transmit timestamp",
+			reply: func(resp []byte) { clear(resp[40:48]) },
+			err:   "transmit timestamp",
+		},
+		{
+			name:  "originate mismatch",
+			reply: func(resp []byte) { resp[31] ^= 0xff },
+			err:   "does not match",
+		},
+		{
+			name: "offset too large",
+			skew: 30 * 365 * 24 * time.Hour,
+			err:  "exceeds",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			addr := serveOnce(t, tt.skew, tt.reply)
+
+			result, err := Query(addr, time.Second)
+			if err == nil {
+				t.Fatalf("Query succeeded with offset %v, want error", result.Offset)
+			}
+			if !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("Query error = %v, want %q", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestNTPTimeRoundTrip(t *testing.T) {
+	now := time.Unix(1700000000, 123456789)
+	got := fromNTPTime(toNTPTime(now))
+	if d := got.Sub(now); d < -time.Microsecond || d > time.Microsecond {
+		t.Errorf("fromNTPTime(toNTPTime(%v)) = %v", now, got)
+	}
+}
@@ -0,0 +1,53 @@
+package otp
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock 验证码计算使用的时钟，测试时可替换为固定时间
+type Clock interface {
+	Now() time.Time
+}
+
+// systemClock 系统时钟
+type systemClock struct{}
+
+func (systemClock) Now() time.Time { return time.Now() }
+
+var (
+	clockMu sync.RWMutex
+	clock   Clock         = systemClock{}
+	offset  time.Duration // 本机时钟偏差补偿，加到时钟读数上
+)
+
+// SetClock replaces the clock used for code generation, nil restores the system clock
+func SetClock(c Clock) {
+	if c == nil {
+		c = systemClock{}
+	}
+	clockMu.Lock()
+	defer clockMu.Unlock()
+	clock = c
+}
+
+// SetOffset sets the correction added to the clock, e.g. the skew measured against an NTP server
+func SetOffset(d time.Duration) {
+	clockMu.Lock()
+	defer clockMu.Unlock()
+	offset = d
+}
+
+// Offset returns the current clock correction
+func Offset() time.Duration {
+	clockMu.RLock()
+	defer clockMu.RUnlock()
+	return offset
+}
+
+// Now returns the corrected time used for all time-based codes
+func Now() time.Time {
+	clockMu.RLock()
+	defer clockMu.RUnlock()
+	return clock.Now().Add(offset)
+}
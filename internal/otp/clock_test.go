@@ -0,0 +1,84 @@
+package otp
+
+import (
+	"testing"
+	"time"
+)
+
+// fixedClock 固定时间的时钟
+type fixedClock time.Time
+
+func (c fixedClock) Now() time.Time { return time.Time(c) }
+
+// useClock 在测试期间替换时钟与偏差，结束后恢复
+func useClock(t *testing.T, now time.Time, offset time.Duration) {
+	t.Helper()
+	SetClock(fixedClock(now))
+	SetOffset(offset)
+	t.Cleanup(func() {
+		SetClock(nil)
+		SetOffset(0)
+	})
+}
+
+func TestSetClockAndOffset(t *testing.T) {
+	now := time.Unix(1111111100, 0)
+	useClock(t, now, 0)
+
+	if got := Now(); !got.Equal(now) {
+		t.Fatalf("Now() = %v, want %v", got, now)
+	}
+
+	SetOffset(9 * time.Second)
+	if got := Offset(); got != 9*time.Second {
+		t.Fatalf("Offset() = %v, want 9s", got)
+	}
+	if got, want := Now(), now.Add(9*time.Second); !got.Equal(want) {
+		t.Fatalf("Now() with offset = %v, want %v", got, want)
+	}
+
+	SetClock(nil)
+	if d := time.Since(Now().Add(-9 * time.Second)); d < -time.Second || d > time.Second {
+		t.Fatalf("SetClock(nil) did not restore the system clock, drift %v", d)
+	}
+}
+
+func TestGenerateTOTPWithClock(t *testing.T) {
+	// RFC 6238 附录 B 的 SHA1 测试密钥 "12345678901234567890"
+	const secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+	tests := []struct {
+		now       int64
+		offset    time.Duration
+		code      string
+		remaining int
+		progress  int
+	}{
+		{now: 59, code: "94287082", remaining: 1, progress: 96},
+		{now: 1111111109, code: "07081804", remaining: 1, progress: 96},
+		{now: 1111111100, offset: 9 * time.Second, code: "07081804", remaining: 1, progress: 96},
+		{now: 1234567890, code: "89005924", remaining: 30, progress: 0},
+		{now: 2000000000, code: "69279037", remaining: 10, progress: 66},
+	}
+
+	for _, tt := range tests {
+		useClock(t, time.Unix(tt.now, 0), tt.offset)
+
+		code, remaining, err := GenerateTOTP(secret, "SHA1", 8, 30)
+		if err != nil {
+			t.Fatalf("GenerateTOTP at %d: %v", tt.now, err)
+		}
+		if code != tt.code {
+			t.Errorf("GenerateTOTP at %d (offset %v) = %s, want %s", tt.now, tt.offset, code, tt.code)
+		}
+		if remaining != tt.remaining {
+			t.Errorf("GenerateTOTP at %d (offset %v) remaining = %d, want %d", tt.now, tt.offset, remaining, tt.remaining)
+		}
+		if got := GetRemainingSeconds(30); got != tt.remaining {
+			t.Errorf("GetRemainingSeconds at %d (offset %v) = %d, want %d", tt.now, tt.offset, got, tt.remaining)
+		}
+		if got := GetProgress(30); got != tt.progress {
+			t.Errorf("GetProgress at %d (offset %v) = %d, want %d", tt.now, tt.offset, got, tt.progress)
+		}
+	}
+}
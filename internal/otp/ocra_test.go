@@ -0,0 +1,129 @@
+package otp
+
+import (
+	"encoding/base32"
+	"encoding/hex"
+	"strings"
+	"testing"
+	"time"
+)
+
+// RFC 6287 附录 C 的测试密钥
+var (
+	ocraSeed20 = ocraTestSecret("3132333435363738393031323334353637383930")
+	ocraSeed32 = ocraTestSecret("3132333435363738393031323334353637383930313233343536373839303132")
+	ocraSeed64 = ocraTestSecret(strings.Repeat("31323334353637383930", 6) + "31323334")
+
+	// 附录 C 时间相关用例使用的时间 "132d0b6"（分钟）
+	ocraTestTime = time.Unix(0x132d0b6*60, 0)
+)
+
+// ocraTestSecret 将十六进制密钥转换为 base32
+func ocraTestSecret(hexKey string) string {
+	key, err := hex.DecodeString(hexKey)
+	if err != nil {
+		panic(err)
+	}
+	return base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(key)
+}
+
+func TestGenerateOCRAVectors(t *testing.T) {
+	tests := []struct {
+		suite  string
+		secret string
+		input  OCRAInput
+		want   string
+	}{
+		// C.1 单向认证
+		{"OCRA-1:HOTP-SHA1-6:QN08", ocraSeed20, OCRAInput{Challenge: "00000000"}, "237653"},
+		{"OCRA-1:HOTP-SHA1-6:QN08", ocraSeed20, OCRAInput{Challenge: "11111111"}, "243178"},
+		{"OCRA-1:HOTP-SHA1-6:QN08", ocraSeed20, OCRAInput{Challenge: "22222222"}, "653583"},
+		{"OCRA-1:HOTP-SHA1-6:QN08", ocraSeed20, OCRAInput{Challenge: "33333333"}, "740991"},
+		{"OCRA-1:HOTP-SHA1-6:QN08", ocraSeed20, OCRAInput{Challenge: "44444444"}, "608993"},
+		{"OCRA-1:HOTP-SHA1-6:QN08", ocraSeed20, OCRAInput{Challenge: "55555555"}, "388898"},
+		{"OCRA-1:HOTP-SHA1-6:QN08", ocraSeed20, OCRAInput{Challenge: "66666666"}, "816933"},
+		{"OCRA-1:HOTP-SHA1-6:QN08", ocraSeed20, OCRAInput{Challenge: "77777777"}, "224598"},
+		{"OCRA-1:HOTP-SHA1-6:QN08", ocraSeed20, OCRAInput{Challenge: "88888888"}, "750600"},
+		{"OCRA-1:HOTP-SHA1-6:QN08", ocraSeed20, OCRAInput{Challenge: "99999999"}, "294470"},
+
+		{"OCRA-1:HOTP-SHA256-8:C-QN08-PSHA1", ocraSeed32, OCRAInput{Counter: 0, Challenge: "12345678", Pin: "1234"}, "65347737"},
+		{"OCRA-1:HOTP-SHA256-8:C-QN08-PSHA1", ocraSeed32, OCRAInput{Counter: 1, Challenge: "12345678", Pin: "1234"}, "86775851"},
+		{"OCRA-1:HOTP-SHA256-8:C-QN08-PSHA1", ocraSeed32, OCRAInput{Counter: 2, Challenge: "12345678", Pin: "1234"}, "78192410"},
+		{"OCRA-1:HOTP-SHA256-8:C-QN08-PSHA1", ocraSeed32, OCRAInput{Counter: 3, Challenge: "12345678", Pin: "1234"}, "71565254"},
+		{"OCRA-1:HOTP-SHA256-8:C-QN08-PSHA1", ocraSeed32, OCRAInput{Counter: 4, Challenge: "12345678", Pin: "1234"}, "10104329"},
+		{"OCRA-1:HOTP-SHA256-8:C-QN08-PSHA1", ocraSeed32, OCRAInput{Counter: 5, Challenge: "12345678", Pin: "1234"}, "65983500"},
+		{"OCRA-1:HOTP-SHA256-8:C-QN08-PSHA1", ocraSeed32, OCRAInput{Counter: 6, Challenge: "12345678", Pin: "1234"}, "70069104"},
+		{"OCRA-1:HOTP-SHA256-8:C-QN08-PSHA1", ocraSeed32, OCRAInput{Counter: 7, Challenge: "12345678", Pin: "1234"}, "91771096"},
+		{"OCRA-1:HOTP-SHA256-8:C-QN08-PSHA1", ocraSeed32, OCRAInput{Counter: 8, Challenge: "12345678", Pin: "1234"}, "75011558"},
+		{"OCRA-1:HOTP-SHA256-8:C-QN08-PSHA1", ocraSeed32, OCRAInput{Counter: 9, Challenge: "12345678", Pin: "1234"}, "08522129"},
+
+		{"OCRA-1:HOTP-SHA256-8:QN08-PSHA1", ocraSeed32, OCRAInput{Challenge: "00000000", Pin: "1234"}, "83238735"},
+		{"OCRA-1:HOTP-SHA256-8:QN08-PSHA1", ocraSeed32, OCRAInput{Challenge: "11111111", Pin: "1234"}, "01501458"},
+		{"OCRA-1:HOTP-SHA256-8:QN08-PSHA1", ocraSeed32, OCRAInput{Challenge: "22222222", Pin: "1234"}, "17957585"},
+		{"OCRA-1:HOTP-SHA256-8:QN08-PSHA1", ocraSeed32, OCRAInput{Challenge: "33333333", Pin: "1234"}, "86776967"},
+		{"OCRA-1:HOTP-SHA256-8:QN08-PSHA1", ocraSeed32, OCRAInput{Challenge: "44444444", Pin: "1234"}, "86807031"},
+
+		{"OCRA-1:HOTP-SHA512-8:C-QN08", ocraSeed64, OCRAInput{Counter: 0, Challenge: "00000000"}, "07016083"},
+		{"OCRA-1:HOTP-SHA512-8:C-QN08", ocraSeed64, OCRAInput{Counter: 1, Challenge: "11111111"}, "63947962"},
+		{"OCRA-1:HOTP-SHA512-8:C-QN08", ocraSeed64, OCRAInput{Counter: 2, Challenge: "22222222"}, "70123924"},
+		{"OCRA-1:HOTP-SHA512-8:C-QN08", ocraSeed64, OCRAInput{Counter: 3, Challenge: "33333333"}, "25341727"},
+		{"OCRA-1:HOTP-SHA512-8:C-QN08", ocraSeed64, OCRAInput{Counter: 4, Challenge: "44444444"}, "33203315"},
+		{"OCRA-1:HOTP-SHA512-8:C-QN08", ocraSeed64, OCRAInput{Counter: 5, Challenge: "55555555"}, "34205738"},
+		{"OCRA-1:HOTP-SHA512-8:C-QN08", ocraSeed64, OCRAInput{Counter: 6, Challenge: "66666666"}, "44343969"},
+		{"OCRA-1:HOTP-SHA512-8:C-QN08", ocraSeed64, OCRAInput{Counter: 7, Challenge: "77777777"}, "51946085"},
+		{"OCRA-1:HOTP-SHA512-8:C-QN08", ocraSeed64, OCRAInput{Counter: 8, Challenge: "88888888"}, "20403879"},
+		{"OCRA-1:HOTP-SHA512-8:C-QN08", ocraSeed64, OCRAInput{Counter: 9, Challenge: "99999999"}, "31409299"},
+
+		{"OCRA-1:HOTP-SHA512-8:QN08-T1M", ocraSeed64, OCRAInput{Challenge: "00000000", Time: ocraTestTime}, "95209754"},
+		{"OCRA-1:HOTP-SHA512-8:QN08-T1M", ocraSeed64, OCRAInput{Challenge: "11111111", Time: ocraTestTime}, "55907591"},
+		{"OCRA-1:HOTP-SHA512-8:QN08-T1M", ocraSeed64, OCRAInput{Challenge: "22222222", Time: ocraTestTime}, "22048402"},
+		{"OCRA-1:HOTP-SHA512-8:QN08-T1M", ocraSeed64, OCRAInput{Challenge: "33333333", Time: ocraTestTime}, "24218844"},
+		{"OCRA-1:HOTP-SHA512-8:QN08-T1M", ocraSeed64, OCRAInput{Challenge: "44444444", Time: ocraTestTime}, "36209546"},
+
+		// C.2 双向认证，挑战为客户端与服务端挑战的拼接
+		{"OCRA-1:HOTP-SHA256-8:QA08", ocraSeed32, OCRAInput{Challenge: "CLI22220SRV11110"}, "28247970"},
+		{"OCRA-1:HOTP-SHA256-8:QA08", ocraSeed32, OCRAInput{Challenge: "SRV11110CLI22220"}, "15510767"},
+
+		// C.3 签名
+		{"OCRA-1:HOTP-SHA256-8:QA08", ocraSeed32, OCRAInput{Challenge: "SIG10000"}, "53095496"},
+		{"OCRA-1:HOTP-SHA512-8:QA10-T1M", ocraSeed64, OCRAInput{Challenge: "SIG1000000", Time: ocraTestTime}, "77537423"},
+	}
+
+	for _, tt := range tests {
+		got, err := GenerateOCRA(tt.suite, tt.secret, tt.input)
+		if err != nil {
+			t.Errorf("GenerateOCRA(%s, %+v): %v", tt.suite, tt.input, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("GenerateOCRA(%s, %+v) = %s, want %s", tt.suite, tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestParseOCRASuite(t *testing.T) {
+	s, err := ParseOCRASuite("OCRA-1:HOTP-SHA1-6:C-QH40-PSHA256-S064-T30S")
+	if err != nil {
+		t.Fatalf("ParseOCRASuite: %v", err)
+	}
+	if s.Algorithm != "SHA1" || s.Digits != 6 || !s.Counter || s.ChallengeFormat != 'H' ||
+		s.ChallengeMaxLen != 40 || s.PinHash != "SHA256" || s.SessionLen != 64 || s.TimeStep != 30*time.Second {
+		t.Errorf("ParseOCRASuite = %+v", s)
+	}
+
+	for _, suite := range []string{
+		"",
+		"OCRA-2:HOTP-SHA1-6:QN08",
+		"OCRA-1:HOTP-MD5-6:QN08",
+		"OCRA-1:HOTP-SHA1-3:QN08",
+		"OCRA-1:HOTP-SHA1-6:C",
+		"OCRA-1:HOTP-SHA1-6:QX08",
+		"OCRA-1:HOTP-SHA1-6:QN65",
+		"OCRA-1:HOTP-SHA1-6:QN08-T1M-PSHA1",
+		"OCRA-1:HOTP-SHA1-6:QN08-T60M",
+	} {
+		if _, err := ParseOCRASuite(suite); err == nil {
+			t.Errorf("ParseOCRASuite(%q) succeeded, want error", suite)
+		}
+	}
+}
@@ -8,26 +8,57 @@ import (
 	"crypto/sha512"
 	"encoding/base32"
 	"encoding/binary"
+	"encoding/hex"
 	"fmt"
 	"hash"
 	"math"
+	"strconv"
 	"strings"
 	"time"
 )
 
+// 账户类型
+const (
+	TypeTOTP   = "TOTP"
+	TypeHOTP   = "HOTP"
+	TypeSteam  = "STEAM"  // Steam Guard：TOTP 截断值映射到 Steam 字母表，固定 5 位、30 秒、SHA1
+	TypeYandex = "YANDEX" // Yandex Key：以 SHA256(PIN + 密钥) 为 HMAC-SHA256 密钥，结果映射为 8 位小写字母
+	TypeMOTP   = "MOTP"   // Mobile-OTP：MD5(epoch/10 + 十六进制密钥 + PIN) 的前 6 位十六进制字符
+	TypeOCRA   = "OCRA"   // OCRA (RFC 6287)：由服务端挑战计算应答，套件保存在 OcraSuite
+)
+
+// Steam Guard 验证码参数
+const (
+	SteamDigits   = 5
+	steamAlphabet = "23456789BCDFGHJKMNPQRTVWXY"
+)
+
+// Yandex Key 与 mOTP 验证码参数
+const (
+	YandexDigits    = 8
+	yandexSecretLen = 16 // 26 位 base32 密钥的前 16 字节为密钥，其余为校验和
+
+	MOTPDigits = 6
+	MOTPPeriod = 10
+)
+
 // Account represents a 2FA account
 type Account struct {
 	ID        string    `json:"id"`
 	Name      string    `json:"name"`
 	Issuer    string    `json:"issuer"`
-	Secret    string    `json:"secret"`    // Base32 encoded
-	Algorithm string    `json:"algorithm"` // SHA1, SHA256, SHA512, MD5
-	Digits    int       `json:"digits"`    // 6 or 8
-	Type      string    `json:"type"`      // TOTP or HOTP
-	Counter   int64     `json:"counter"`   // For HOTP
-	Period    int       `json:"period"`    // For TOTP, default 30
+	Secret    string    `json:"secret"`     // Base32 encoded
+	Algorithm string    `json:"algorithm"`  // SHA1, SHA256, SHA512, MD5
+	Digits    int       `json:"digits"`     // 1-10, usually 6 or 8
+	Type      string    `json:"type"`       // TOTP, HOTP, STEAM, YANDEX, MOTP or OCRA
+	Counter   int64     `json:"counter"`    // For HOTP and OCRA suites with C
+	Period    int       `json:"period"`     // For TOTP, default 30
+	Pin       string    `json:"pin"`        // For YANDEX, MOTP and OCRA suites with P
+	OcraSuite string    `json:"ocra_suite"` // For OCRA, e.g. OCRA-1:HOTP-SHA1-6:QN08
 	CreatedAt time.Time `json:"created_at"`
 	Group     string    `json:"group"`     // 分组名称（本工具独有）
+	Note      string    `json:"note"`      // 备注
+	Icon      string    `json:"icon"`      // 图标：来源应用的缩略图名称或图片 data URL
 }
 
 // GenerateTOTP generates a TOTP code for the given account
@@ -37,7 +68,8 @@ func GenerateTOTP(secret, algorithm string, digits int, period int) (string, int
 	}
 
 	// Get current time counter
-	counter := time.Now().Unix() / int64(period)
+	now := Now().Unix()
+	counter := now / int64(period)
 
 	// Generate code
 	code, err := generateCode(secret, counter, algorithm, digits)
@@ -46,7 +78,7 @@ func GenerateTOTP(secret, algorithm string, digits int, period int) (string, int
 	}
 
 	// Calculate remaining seconds
-	remaining := period - int(time.Now().Unix()%int64(period))
+	remaining := period - int(now%int64(period))
 
 	return code, remaining, nil
 }
@@ -56,13 +88,139 @@ func GenerateHOTP(secret, algorithm string, digits int, counter int64) (string,
 	return generateCode(secret, counter, algorithm, digits)
 }
 
+// GenerateSteam generates a Steam Guard code for the current 30-second period
+func GenerateSteam(secret string) (string, int, error) {
+	const period = 30
+	now := Now().Unix()
+	counter := now / period
+
+	value, err := truncatedHMAC(secret, counter, "SHA1")
+	if err != nil {
+		return "", 0, err
+	}
+
+	// 依次取余映射到 26 个字符
+	code := make([]byte, SteamDigits)
+	for i := range code {
+		code[i] = steamAlphabet[value%uint32(len(steamAlphabet))]
+		value /= uint32(len(steamAlphabet))
+	}
+
+	remaining := period - int(now%period)
+	return string(code), remaining, nil
+}
+
+// GenerateYandex generates a Yandex Key code, period defaults to 30
+func GenerateYandex(secret, pin string, period int) (string, int, error) {
+	if period == 0 {
+		period = 30
+	}
+	if pin == "" {
+		return "", 0, fmt.Errorf("missing PIN")
+	}
+	secretBytes, err := DecodeSecret(secret)
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid secret: %w", err)
+	}
+	if len(secretBytes) > yandexSecretLen {
+		secretBytes = secretBytes[:yandexSecretLen]
+	}
+
+	// 密钥 = SHA256(PIN + 密钥)，首字节为 0 时去掉
+	key := sha256.Sum256(append([]byte(pin), secretBytes...))
+	keyBytes := key[:]
+	if keyBytes[0] == 0 {
+		keyBytes = keyBytes[1:]
+	}
+
+	now := Now().Unix()
+	mac := hmac.New(sha256.New, keyBytes)
+	counterBytes := make([]byte, 8)
+	binary.BigEndian.PutUint64(counterBytes, uint64(now/int64(period)))
+	mac.Write(counterBytes)
+	hash := mac.Sum(nil)
+
+	// 与 RFC 4226 相同的偏移，但截取 63 位
+	offset := hash[len(hash)-1] & 0x0f
+	value := binary.BigEndian.Uint64(hash[offset:offset+8]) & 0x7fffffffffffffff
+	value %= uint64(math.Pow(26, YandexDigits))
+
+	code := make([]byte, YandexDigits)
+	for i := len(code) - 1; i >= 0; i-- {
+		code[i] = byte('a' + value%26)
+		value /= 26
+	}
+
+	remaining := period - int(now%int64(period))
+	return string(code), remaining, nil
+}
+
+// GenerateMOTP generates a Mobile-OTP code for the current 10-second period
+func GenerateMOTP(secret, pin string) (string, int, error) {
+	if pin == "" {
+		return "", 0, fmt.Errorf("missing PIN")
+	}
+	secretBytes, err := DecodeSecret(secret)
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid secret: %w", err)
+	}
+
+	// mOTP 的密钥原本就是十六进制字符串，保存时转为 base32
+	now := Now().Unix()
+	input := strconv.FormatInt(now/MOTPPeriod, 10) + hex.EncodeToString(secretBytes) + pin
+	sum := md5.Sum([]byte(input))
+
+	remaining := MOTPPeriod - int(now%MOTPPeriod)
+	return hex.EncodeToString(sum[:])[:MOTPDigits], remaining, nil
+}
+
+// MOTPSecretFromHex converts a hexadecimal mOTP secret to the base32 form stored in Account
+func MOTPSecretFromHex(secret string) (string, error) {
+	secretBytes, err := hex.DecodeString(strings.TrimSpace(secret))
+	if err != nil {
+		return "", fmt.Errorf("invalid mOTP secret: %w", err)
+	}
+	if len(secretBytes) == 0 {
+		return "", fmt.Errorf("empty secret")
+	}
+	return base32.StdEncoding.EncodeToString(secretBytes), nil
+}
+
+// DecodeSecret decodes a base32 secret, tolerating lowercase, spaces, dashes and missing padding
+func DecodeSecret(secret string) ([]byte, error) {
+	secret = strings.ToUpper(secret)
+	secret = strings.NewReplacer(" ", "", "-", "", "=", "").Replace(secret)
+	return base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(secret)
+}
+
 // generateCode is the core HMAC-based One-Time Password algorithm
 func generateCode(secret string, counter int64, algorithm string, digits int) (string, error) {
+	if digits == 0 {
+		digits = 6
+	}
+	if digits < 1 || digits > 10 {
+		return "", fmt.Errorf("invalid digit count: %d", digits)
+	}
+
+	truncated, err := truncatedHMAC(secret, counter, algorithm)
+	if err != nil {
+		return "", err
+	}
+
+	// Generate OTP (10 digits exceeds uint32)
+	otp := uint64(truncated) % uint64(math.Pow10(digits))
+
+	// Format with leading zeros
+	format := fmt.Sprintf("%%0%dd", digits)
+	return fmt.Sprintf(format, otp), nil
+}
+
+// truncatedHMAC computes the RFC 4226 dynamically truncated 31-bit HMAC value
+func truncatedHMAC(secret string, counter int64, algorithm string) (uint32, error) {
 	// Decode base32 secret
-	secret = strings.ToUpper(strings.ReplaceAll(secret, " ", ""))
-	secretBytes, err := base32.StdEncoding.DecodeString(secret)
+	secretBytes, err := DecodeSecret(secret)
 	if err != nil {
-		return "", fmt.Errorf("invalid secret: %w", err)
+		return 0, fmt.Errorf("invalid secret: %w", err)
 	}
 
 	// Create HMAC hash function
@@ -89,14 +247,7 @@ func generateCode(secret string, counter int64, algorithm string, digits int) (s
 
 	// Dynamic truncation
 	offset := hash[len(hash)-1] & 0x0f
-	truncated := binary.BigEndian.Uint32(hash[offset:offset+4]) & 0x7fffffff
-
-	// Generate OTP
-	otp := truncated % uint32(math.Pow10(digits))
-
-	// Format with leading zeros
-	format := fmt.Sprintf("%%0%dd", digits)
-	return fmt.Sprintf(format, otp), nil
+	return binary.BigEndian.Uint32(hash[offset:offset+4]) & 0x7fffffff, nil
 }
 
 // ValidateTOTP validates a TOTP code
@@ -122,7 +273,7 @@ func GetRemainingSeconds(period int) int {
 	if period == 0 {
 		period = 30
 	}
-	return period - int(time.Now().Unix()%int64(period))
+	return period - int(Now().Unix()%int64(period))
 }
 
 // GetProgress returns the progress percentage (0-100) for the current TOTP period
@@ -130,6 +281,6 @@ func GetProgress(period int) int {
 	if period == 0 {
 		period = 30
 	}
-	elapsed := int(time.Now().Unix() % int64(period))
+	elapsed := int(Now().Unix() % int64(period))
 	return (elapsed * 100) / period
 }
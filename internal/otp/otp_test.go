@@ -0,0 +1,105 @@
+package otp
+
+import (
+	"testing"
+	"time"
+)
+
+// 测试向量来自 ValvePython/steam 的 tests/test_guard.py，密钥为 "superdupersecret"
+func TestGenerateSteamVectors(t *testing.T) {
+	const secret = "ON2XAZLSMR2XAZLSONSWG4TFOQ======"
+
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{3000029, "94R9D"},
+		{3000030, "YRGQJ"},
+	}
+	for _, tt := range tests {
+		useClock(t, time.Unix(tt.unix, 0), 0)
+		code, remaining, err := GenerateSteam(secret)
+		if err != nil {
+			t.Fatalf("GenerateSteam at %d: %v", tt.unix, err)
+		}
+		if code != tt.want {
+			t.Errorf("GenerateSteam at %d = %q, want %q", tt.unix, code, tt.want)
+		}
+		if want := 30 - int(tt.unix%30); remaining != want {
+			t.Errorf("GenerateSteam at %d remaining = %d, want %d", tt.unix, remaining, want)
+		}
+	}
+}
+
+// 测试向量来自 Aegis 的 YandexTest，密钥为 26 字节（16 字节密钥 + 校验数据），只使用前 16 字节
+func TestGenerateYandexVectors(t *testing.T) {
+	tests := []struct {
+		pin    string
+		secret string
+		unix   int64
+		want   string
+	}{
+		{"5239", "6SB2IKNM6OBZPAVBVTOHDKS4FAAAAAAADFUTQMBTRY", 1641559648, "umozdicq"},
+		{"7586", "LA2V6KMCGYMWWVEW64RNP3JA3IAAAAAAHTSG4HRZPI", 1581064020, "oactmacq"},
+		{"7586", "LA2V6KMCGYMWWVEW64RNP3JA3IAAAAAAHTSG4HRZPI", 1581090810, "wemdwrix"},
+		{"5210481216086702", "JBGSAU4G7IEZG6OY4UAXX62JU4AAAAAAHTSG4HXU3M", 1581091469, "dfrpywob"},
+		{"5210481216086702", "JBGSAU4G7IEZG6OY4UAXX62JU4AAAAAAHTSG4HXU3M", 1581093059, "vunyprpd"},
+	}
+	for _, tt := range tests {
+		useClock(t, time.Unix(tt.unix, 0), 0)
+		code, _, err := GenerateYandex(tt.secret, tt.pin, 30)
+		if err != nil {
+			t.Fatalf("GenerateYandex at %d: %v", tt.unix, err)
+		}
+		if code != tt.want {
+			t.Errorf("GenerateYandex(pin %s) at %d = %q, want %q", tt.pin, tt.unix, code, tt.want)
+		}
+	}
+
+	// PIN 参与密钥派生，换一个 PIN 得到不同的验证码
+	useClock(t, time.Unix(1641559648, 0), 0)
+	if code, _, _ := GenerateYandex("6SB2IKNM6OBZPAVBVTOHDKS4FAAAAAAADFUTQMBTRY", "5238", 30); code == "umozdicq" {
+		t.Error("GenerateYandex ignores the PIN")
+	}
+	if _, _, err := GenerateYandex("6SB2IKNM6OBZPAVBVTOHDKS4FAAAAAAADFUTQMBTRY", "", 30); err == nil {
+		t.Error("GenerateYandex without PIN: expected error")
+	}
+}
+
+// mOTP 参考定义：MD5(epoch/10 + 十六进制密钥 + PIN) 的前 6 个十六进制字符，
+// 以下向量按该定义用独立实现（Python hashlib）计算
+func TestGenerateMOTPVectors(t *testing.T) {
+	secret, err := MOTPSecretFromHex("e3152afee62599c8")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{165892298, "e7d8b6"},
+		{123456789, "4ebfb2"},
+	}
+	for _, tt := range tests {
+		useClock(t, time.Unix(tt.unix, 0), 0)
+		code, remaining, err := GenerateMOTP(secret, "1234")
+		if err != nil {
+			t.Fatalf("GenerateMOTP at %d: %v", tt.unix, err)
+		}
+		if code != tt.want {
+			t.Errorf("GenerateMOTP at %d = %q, want %q", tt.unix, code, tt.want)
+		}
+		if want := MOTPPeriod - int(tt.unix%MOTPPeriod); remaining != want {
+			t.Errorf("GenerateMOTP at %d remaining = %d, want %d", tt.unix, remaining, want)
+		}
+	}
+
+	useClock(t, time.Unix(165892298, 0), 0)
+	if code, _, _ := GenerateMOTP(secret, "1235"); code == "e7d8b6" {
+		t.Error("GenerateMOTP ignores the PIN")
+	}
+	if _, _, err := GenerateMOTP(secret, ""); err == nil {
+		t.Error("GenerateMOTP without PIN: expected error")
+	}
+}
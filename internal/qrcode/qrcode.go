@@ -8,6 +8,7 @@ import (
 	"image/color"
 	_ "image/jpeg"
 	_ "image/png"
+	"strings"
 
 	"github.com/liyue201/goqr"
 	"github.com/makiuchi-d/gozxing"
@@ -209,6 +210,29 @@ func ScanQRCodeFromBytes(data []byte) (string, error) {
 	return ScanQRCode(img)
 }
 
+// 二维码纠错等级，等级越低同样内容生成的图案越稀疏，越容易被手机扫描
+const (
+	LevelLow      = "L" // 约 7% 纠错
+	LevelMedium   = "M" // 约 15% 纠错
+	LevelQuartile = "Q" // 约 25% 纠错
+	LevelHigh     = "H" // 约 30% 纠错
+)
+
+// parseLevel 将纠错等级字符串转换为 go-qrcode 的等级
+// 未知值沿用 GenerateQRCode 的默认等级（25%）
+func parseLevel(level string) qr.RecoveryLevel {
+	switch strings.ToUpper(level) {
+	case LevelLow:
+		return qr.Low
+	case LevelMedium:
+		return qr.Medium
+	case LevelHigh:
+		return qr.Highest
+	default:
+		return qr.High
+	}
+}
+
 // GenerateQRCode generates a QR code image from text
 func GenerateQRCode(text string, size int) ([]byte, error) {
 	if size == 0 {
@@ -224,6 +248,20 @@ func GenerateQRCode(text string, size int) ([]byte, error) {
 	return png, nil
 }
 
+// GenerateQRCodeWithLevel generates a QR code image with the given recovery level (L/M/Q/H)
+func GenerateQRCodeWithLevel(text string, size int, level string) ([]byte, error) {
+	if size == 0 {
+		size = 512 // Default size
+	}
+
+	png, err := qr.Encode(text, parseLevel(level), size)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate QR code: %w", err)
+	}
+
+	return png, nil
+}
+
 // GenerateQRCodeBase64 generates a QR code and returns it as base64 data URL
 func GenerateQRCodeBase64(text string, size int) (string, error) {
 	png, err := GenerateQRCode(text, size)
@@ -235,6 +273,17 @@ func GenerateQRCodeBase64(text string, size int) (string, error) {
 	return fmt.Sprintf("data:image/png;base64,%s", encoded), nil
 }
 
+// GenerateQRCodeBase64WithLevel generates a QR code with the given recovery level and returns it as base64 data URL
+func GenerateQRCodeBase64WithLevel(text string, size int, level string) (string, error) {
+	png, err := GenerateQRCodeWithLevel(text, size, level)
+	if err != nil {
+		return "", err
+	}
+
+	encoded := base64.StdEncoding.EncodeToString(png)
+	return fmt.Sprintf("data:image/png;base64,%s", encoded), nil
+}
+
 // GenerateQRCodeImage generates a QR code and returns it as an image.Image
 func GenerateQRCodeImage(text string, size int) (image.Image, error) {
 	png, err := GenerateQRCode(text, size)
@@ -0,0 +1,210 @@
+package storage
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"database/sql"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// 可移植备份文件（.gabak）格式，所有整数为大端序：
+//
+//	magic "GABK"(4) | version(2) | argon2 time(4) | memory KiB(4) | threads(1) | salt(16) | nonce(12) | ciphertext+tag
+//
+// 密钥由备份密码经 Argon2id 派生，与设备无关；整个头部作为 AES-256-GCM 的附加数据参与认证，
+// 因此篡改 KDF 参数或版本号都会导致解密失败。
+const (
+	BackupFileExt = ".gabak"
+
+	backupMagic     = "GABK"
+	backupVersion   = 1
+	backupHeaderLen = 4 + 2 + 4 + 4 + 1 + saltLen + nonceLen
+)
+
+var (
+	ErrNotBackup            = errors.New("not a backup file")
+	ErrBackupPassword       = errors.New("wrong backup password or corrupted backup")
+	ErrBackupPasswordNeeded = errors.New("backup password required")
+)
+
+// Backup 可移植备份的内容
+type Backup struct {
+	CreatedAt time.Time `json:"created_at"`
+	Accounts  []Account `json:"accounts"`
+	Settings  Settings  `json:"settings"`
+}
+
+// ExportBackup 将全部账户和设置导出为使用备份密码加密的 .gabak 数据
+func (d *Database) ExportBackup(password string) ([]byte, error) {
+	if password == "" {
+		return nil, ErrBackupPasswordNeeded
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if err := d.ensureUnlocked(); err != nil {
+		return nil, err
+	}
+	accounts, err := d.getAllAccountsInternal()
+	if err != nil {
+		return nil, err
+	}
+	settings, err := d.getSettingsInternal()
+	if err != nil {
+		settings = DefaultSettings()
+	}
+
+	return SealBackup(Backup{
+		CreatedAt: time.Now().UTC(),
+		Accounts:  accounts,
+		Settings:  settings,
+	}, password)
+}
+
+// SealBackup 使用备份密码加密备份内容
+func SealBackup(b Backup, password string) ([]byte, error) {
+	if password == "" {
+		return nil, ErrBackupPasswordNeeded
+	}
+
+	payload, err := json.Marshal(b)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal backup: %w", err)
+	}
+
+	salt, err := GenerateSalt()
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, nonceLen)
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	params := DefaultKDFParams()
+	var header bytes.Buffer
+	header.WriteString(backupMagic)
+	binary.Write(&header, binary.BigEndian, uint16(backupVersion))
+	binary.Write(&header, binary.BigEndian, params.Time)
+	binary.Write(&header, binary.BigEndian, params.Memory)
+	header.WriteByte(params.Threads)
+	header.Write(salt)
+	header.Write(nonce)
+
+	gcm, err := newGCM(params.DeriveKey(password, salt))
+	if err != nil {
+		return nil, err
+	}
+	return gcm.Seal(header.Bytes(), nonce, payload, header.Bytes()), nil
+}
+
+// OpenBackup 校验并解密 .gabak 数据
+func OpenBackup(data []byte, password string) (*Backup, error) {
+	if len(data) < backupHeaderLen || string(data[:4]) != backupMagic {
+		return nil, ErrNotBackup
+	}
+	if version := binary.BigEndian.Uint16(data[4:6]); version != backupVersion {
+		return nil, fmt.Errorf("unsupported backup version: %d", version)
+	}
+	if password == "" {
+		return nil, ErrBackupPasswordNeeded
+	}
+
+	params := KDFParams{
+		Time:    binary.BigEndian.Uint32(data[6:10]),
+		Memory:  binary.BigEndian.Uint32(data[10:14]),
+		Threads: data[14],
+	}
+	if err := params.Validate(); err != nil {
+		return nil, err
+	}
+	salt := data[15 : 15+saltLen]
+	nonce := data[15+saltLen : backupHeaderLen]
+	header := data[:backupHeaderLen]
+
+	gcm, err := newGCM(params.DeriveKey(password, salt))
+	if err != nil {
+		return nil, err
+	}
+	payload, err := gcm.Open(nil, nonce, data[backupHeaderLen:], header)
+	if err != nil {
+		return nil, ErrBackupPassword
+	}
+
+	var b Backup
+	if err := json.Unmarshal(payload, &b); err != nil {
+		return nil, fmt.Errorf("invalid backup content: %w", err)
+	}
+	return &b, nil
+}
+
+// RestoreBackup 用备份内容替换全部账户和设置
+// 密码保护状态属于本机数据库，不随备份恢复
+func (d *Database) RestoreBackup(b *Backup) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if err := d.ensureUnlocked(); err != nil {
+		return err
+	}
+	if _, err := d.snapshotLocked(SnapshotReasonRestore); err != nil {
+		return err
+	}
+
+	current, err := d.getSettingsInternal()
+	if err != nil {
+		current = DefaultSettings()
+	}
+	settings := b.Settings
+	settings.PasswordEnabled = current.PasswordEnabled
+
+	return d.withTx(func(tx *sql.Tx) error {
+		if err := saveSettings(tx, d.masterKey, settings); err != nil {
+			return err
+		}
+		// 已有账户原地重写以保留创建时间，备份中新增的账户插入，不在备份中的账户删除
+		ids := make([]any, 0, len(b.Accounts))
+		for _, acc := range b.Accounts {
+			updated, err := reencryptAccount(tx, d.masterKey, acc)
+			if err != nil {
+				return err
+			}
+			if !updated {
+				if err := saveAccount(tx, d.masterKey, acc); err != nil {
+					return err
+				}
+			}
+			ids = append(ids, acc.ID)
+		}
+		query := "DELETE FROM accounts"
+		if len(ids) > 0 {
+			query += " WHERE id NOT IN (?" + strings.Repeat(", ?", len(ids)-1) + ")"
+		}
+		if _, err := tx.Exec(query, ids...); err != nil {
+			return fmt.Errorf("failed to delete accounts: %w", err)
+		}
+		return nil
+	})
+}
+
+// newGCM 创建 AES-256-GCM 实例
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create GCM: %w", err)
+	}
+	return gcm, nil
+}
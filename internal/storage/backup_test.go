@@ -0,0 +1,157 @@
+package storage
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+func testBackup() Backup {
+	return Backup{
+		CreatedAt: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		Accounts: []Account{
+			{ID: "1", Name: "alice@example.com", Issuer: "Example", Secret: "JBSWY3DPEHPK3PXP", Algorithm: "SHA1", Digits: 6, Type: "TOTP", Period: 30},
+			{ID: "2", Name: "bob", Issuer: "Bank", Secret: "GEZDGNBVGY3TQOJQ", Algorithm: "SHA256", Digits: 8, Type: "HOTP", Counter: 7},
+		},
+		Settings: DefaultSettings(),
+	}
+}
+
+func TestBackupRoundTrip(t *testing.T) {
+	want := testBackup()
+	data, err := SealBackup(want, "backup password")
+	if err != nil {
+		t.Fatalf("SealBackup: %v", err)
+	}
+
+	got, err := OpenBackup(data, "backup password")
+	if err != nil {
+		t.Fatalf("OpenBackup: %v", err)
+	}
+	if !got.CreatedAt.Equal(want.CreatedAt) || len(got.Accounts) != len(want.Accounts) {
+		t.Fatalf("OpenBackup = %+v, want %+v", got, want)
+	}
+	for i := range want.Accounts {
+		if got.Accounts[i] != want.Accounts[i] {
+			t.Errorf("account %d = %+v, want %+v", i, got.Accounts[i], want.Accounts[i])
+		}
+	}
+}
+
+func TestBackupWrongPassword(t *testing.T) {
+	data, err := SealBackup(testBackup(), "backup password")
+	if err != nil {
+		t.Fatalf("SealBackup: %v", err)
+	}
+
+	if _, err := OpenBackup(data, "wrong password"); !errors.Is(err, ErrBackupPassword) {
+		t.Errorf("wrong password: err = %v, want ErrBackupPassword", err)
+	}
+	if _, err := OpenBackup(data, ""); !errors.Is(err, ErrBackupPasswordNeeded) {
+		t.Errorf("empty password: err = %v, want ErrBackupPasswordNeeded", err)
+	}
+	if _, err := SealBackup(testBackup(), ""); !errors.Is(err, ErrBackupPasswordNeeded) {
+		t.Errorf("seal without password: err = %v, want ErrBackupPasswordNeeded", err)
+	}
+}
+
+func TestBackupTamperedHeader(t *testing.T) {
+	data, err := SealBackup(testBackup(), "backup password")
+	if err != nil {
+		t.Fatalf("SealBackup: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		tamper func(b []byte)
+		want   error
+	}{
+		{"magic", func(b []byte) { b[0] = 'X' }, ErrNotBackup},
+		{"argon2 time", func(b []byte) { binary.BigEndian.PutUint32(b[6:10], binary.BigEndian.Uint32(b[6:10])+1) }, ErrBackupPassword},
+		{"salt", func(b []byte) { b[15] ^= 0x01 }, ErrBackupPassword},
+		{"nonce", func(b []byte) { b[backupHeaderLen-1] ^= 0x01 }, ErrBackupPassword},
+		{"ciphertext", func(b []byte) { b[len(b)-1] ^= 0x01 }, ErrBackupPassword},
+	}
+	for _, tt := range tests {
+		tampered := append([]byte{}, data...)
+		tt.tamper(tampered)
+		if _, err := OpenBackup(tampered, "backup password"); !errors.Is(err, tt.want) {
+			t.Errorf("%s: err = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+
+	version := append([]byte{}, data...)
+	binary.BigEndian.PutUint16(version[4:6], backupVersion+1)
+	if _, err := OpenBackup(version, "backup password"); err == nil {
+		t.Error("unsupported version: expected error")
+	}
+
+	if _, err := OpenBackup(data[:backupHeaderLen-1], "backup password"); !errors.Is(err, ErrNotBackup) {
+		t.Errorf("truncated: err = %v, want ErrNotBackup", err)
+	}
+}
+
+// 头部必须作为附加数据参与认证：用相同密钥和 nonce 但不带附加数据加密的文件应被拒绝
+func TestBackupHeaderIsAuthenticated(t *testing.T) {
+	data, err := SealBackup(testBackup(), "backup password")
+	if err != nil {
+		t.Fatalf("SealBackup: %v", err)
+	}
+
+	params := KDFParams{
+		Time:    binary.BigEndian.Uint32(data[6:10]),
+		Memory:  binary.BigEndian.Uint32(data[10:14]),
+		Threads: data[14],
+	}
+	salt := data[15 : 15+saltLen]
+	nonce := data[15+saltLen : backupHeaderLen]
+	gcm, err := newGCM(params.DeriveKey("backup password", salt))
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload, err := json.Marshal(testBackup())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	forged := gcm.Seal(append([]byte{}, data[:backupHeaderLen]...), nonce, payload, nil)
+	if _, err := OpenBackup(forged, "backup password"); !errors.Is(err, ErrBackupPassword) {
+		t.Errorf("header not used as AAD: err = %v, want ErrBackupPassword", err)
+	}
+}
+
+func TestRestoreBackupPreservesCreatedAt(t *testing.T) {
+	d := newTestDatabase(t)
+	for _, acc := range []Account{
+		{ID: "1", Name: "alice@example.com", Issuer: "Example", Secret: "JBSWY3DPEHPK3PXP"},
+		{ID: "stale", Name: "carol", Issuer: "Old", Secret: "MFRGGZDFMZTWQ2LK"},
+	} {
+		if err := d.SaveAccount(acc); err != nil {
+			t.Fatal(err)
+		}
+	}
+	setCreatedAt(t, d, "1", 1000)
+
+	b := testBackup()
+	if err := d.RestoreBackup(&b); err != nil {
+		t.Fatalf("RestoreBackup: %v", err)
+	}
+	if got := createdAt(t, d, "1"); got != 1000 {
+		t.Errorf("created_at of restored account = %d, want 1000", got)
+	}
+
+	accounts, err := d.GetAllAccounts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(accounts) != len(b.Accounts) {
+		t.Fatalf("got %d accounts, want %d: %+v", len(accounts), len(b.Accounts), accounts)
+	}
+	for i := range b.Accounts {
+		if accounts[i] != b.Accounts[i] {
+			t.Errorf("account %d = %+v, want %+v", i, accounts[i], b.Accounts[i])
+		}
+	}
+}
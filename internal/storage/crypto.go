@@ -34,9 +34,40 @@ var (
 	ErrInvalidData      = errors.New("invalid encrypted data format")
 )
 
-// DeriveKey 使用 Argon2id 从密码派生密钥
+// KDFParams Argon2id 参数
+type KDFParams struct {
+	Time    uint32 `json:"time"`
+	Memory  uint32 `json:"memory"` // KiB
+	Threads uint8  `json:"threads"`
+}
+
+// DefaultKDFParams 默认的 Argon2id 参数
+func DefaultKDFParams() KDFParams {
+	return KDFParams{Time: argonTime, Memory: argonMemory, Threads: argonThreads}
+}
+
+// Validate 检查来自文件等外部来源的参数是否在合理范围内，避免派生时耗尽内存
+func (p KDFParams) Validate() error {
+	if p.Time < 1 || p.Time > 64 {
+		return fmt.Errorf("invalid Argon2id time parameter: %d", p.Time)
+	}
+	if p.Memory < 8*uint32(p.Threads) || p.Memory > 1024*1024 {
+		return fmt.Errorf("invalid Argon2id memory parameter: %d KiB", p.Memory)
+	}
+	if p.Threads < 1 {
+		return fmt.Errorf("invalid Argon2id threads parameter: %d", p.Threads)
+	}
+	return nil
+}
+
+// DeriveKey 使用当前参数从密码派生密钥
+func (p KDFParams) DeriveKey(password string, salt []byte) []byte {
+	return argon2.IDKey([]byte(password), salt, p.Time, p.Memory, p.Threads, argonKeyLen)
+}
+
+// DeriveKey 使用默认参数的 Argon2id 从密码派生密钥
 func DeriveKey(password string, salt []byte) []byte {
-	return argon2.IDKey([]byte(password), salt, argonTime, argonMemory, argonThreads, argonKeyLen)
+	return DefaultKDFParams().DeriveKey(password, salt)
 }
 
 // GenerateSalt 生成随机盐值
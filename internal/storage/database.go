@@ -8,6 +8,7 @@ import (
 	"os"
 	"path/filepath"
 	"sync"
+	"time"
 
 	_ "modernc.org/sqlite"
 )
@@ -17,6 +18,16 @@ const (
 	verifierConstant = "AUTHENTICATOR_KEY_VERIFIER_V1"
 )
 
+// execer 由 *sql.DB 和 *sql.Tx 实现，使写入既可单独执行也可在事务中执行
+type execer interface {
+	Exec(query string, args ...any) (sql.Result, error)
+}
+
+// querier 由 *sql.DB 和 *sql.Tx 实现
+type querier interface {
+	QueryRow(query string, args ...any) *sql.Row
+}
+
 // Database 封装数据库操作
 type Database struct {
 	db        *sql.DB
@@ -36,7 +47,11 @@ type Account struct {
 	Type      string `json:"type"`
 	Counter   int64  `json:"counter"`
 	Period    int    `json:"period"`
+	Pin       string `json:"pin"`
+	OcraSuite string `json:"ocra_suite"`
 	Group     string `json:"group"`
+	Note      string `json:"note"`
+	Icon      string `json:"icon"`
 }
 
 // Settings 设置结构
@@ -44,6 +59,17 @@ type Settings struct {
 	PasswordEnabled bool   `json:"password_enabled"`
 	Theme           string `json:"theme"`
 	AutoLockMinutes int    `json:"auto_lock_minutes"`
+	DuplicatePolicy string `json:"duplicate_policy"` // 导入重复账户的处理策略，见 MergePolicy
+
+	// 快照设置，零值表示使用默认值
+	SnapshotDir           string `json:"snapshot_dir"`            // 快照目录，空为数据库目录下的 snapshots
+	SnapshotKeep          int    `json:"snapshot_keep"`           // 保留份数，0 为 DefaultSnapshotKeep
+	SnapshotIntervalHours int    `json:"snapshot_interval_hours"` // 定时快照间隔（小时），0 为默认，负数关闭
+
+	// 时间校准
+	NTPServer         string `json:"ntp_server"`          // 时间服务器，空为 ntp.DefaultServer
+	ClockOffsetMillis int64  `json:"clock_offset_millis"` // 本机时钟偏差补偿（毫秒），加到生成验证码使用的时间上
+	AutoTimeSync      bool   `json:"auto_time_sync"`      // 解锁后自动与时间服务器同步
 }
 
 // DefaultSettings 默认设置
@@ -52,6 +78,7 @@ func DefaultSettings() Settings {
 		PasswordEnabled: false,
 		Theme:           "light",
 		AutoLockMinutes: 5,
+		DuplicatePolicy: string(PolicySkip),
 	}
 }
 
@@ -65,17 +92,9 @@ func decodeBytes(encoded string) ([]byte, error) {
 	return base64.StdEncoding.DecodeString(encoded)
 }
 
-// NewDatabase 创建数据库实例
-func NewDatabase() (*Database, error) {
-	// 获取可执行文件所在目录
-	execPath, err := os.Executable()
-	if err != nil {
-		return nil, fmt.Errorf("failed to get executable path: %w", err)
-	}
-	execDir := filepath.Dir(execPath)
-
-	// 创建 data 目录
-	dataDir := filepath.Join(execDir, "data")
+// NewDatabase 在数据目录中打开或创建数据库，数据目录由 ResolveDataDir 确定
+func NewDatabase(dataDir string) (*Database, error) {
+	// 创建数据目录
 	if err := os.MkdirAll(dataDir, 0700); err != nil {
 		return nil, fmt.Errorf("failed to create data directory: %w", err)
 	}
@@ -88,8 +107,8 @@ func NewDatabase() (*Database, error) {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
 
-	// 初始化表结构
-	if err := initTables(db); err != nil {
+	// 初始化或升级表结构
+	if err := migrateSchema(db, dbPath); err != nil {
 		db.Close()
 		return nil, err
 	}
@@ -100,33 +119,6 @@ func NewDatabase() (*Database, error) {
 	}, nil
 }
 
-// initTables 初始化数据库表
-func initTables(db *sql.DB) error {
-	schema := `
-	CREATE TABLE IF NOT EXISTS metadata (
-		key TEXT PRIMARY KEY,
-		value TEXT NOT NULL
-	);
-
-	CREATE TABLE IF NOT EXISTS accounts (
-		id TEXT PRIMARY KEY,
-		data TEXT NOT NULL
-	);
-
-	CREATE TABLE IF NOT EXISTS settings (
-		key TEXT PRIMARY KEY,
-		value TEXT NOT NULL
-	);
-	`
-
-	_, err := db.Exec(schema)
-	if err != nil {
-		return fmt.Errorf("failed to create tables: %w", err)
-	}
-
-	return nil
-}
-
 // Close 关闭数据库连接
 func (d *Database) Close() error {
 	if d.db != nil {
@@ -161,28 +153,34 @@ func (d *Database) Initialize() error {
 	}
 
 	// 使用设备密钥
-	d.masterKey = GetDeviceKey()
+	key := GetDeviceKey()
 
-	// 保存盐值（Base64 编码）
-	_, err = d.db.Exec("INSERT OR REPLACE INTO metadata (key, value) VALUES ('salt', ?)",
-		encodeBytes(salt))
-	if err != nil {
-		return fmt.Errorf("failed to save salt: %w", err)
-	}
+	err = d.withTx(func(tx *sql.Tx) error {
+		// 保存盐值（Base64 编码）
+		if _, err := tx.Exec("INSERT OR REPLACE INTO metadata (key, value) VALUES ('salt', ?)",
+			encodeBytes(salt)); err != nil {
+			return fmt.Errorf("failed to save salt: %w", err)
+		}
+
+		// 创建验证器（用于验证密钥正确性）
+		verifier, err := Encrypt([]byte(verifierConstant), key)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec("INSERT OR REPLACE INTO metadata (key, value) VALUES ('device_verifier', ?)",
+			encodeBytes(verifier)); err != nil {
+			return fmt.Errorf("failed to save verifier: %w", err)
+		}
 
-	// 创建验证器（用于验证密钥正确性）
-	verifier, err := Encrypt([]byte(verifierConstant), d.masterKey)
+		// 保存默认设置
+		return saveSettings(tx, key, DefaultSettings())
+	})
 	if err != nil {
 		return err
 	}
-	_, err = d.db.Exec("INSERT OR REPLACE INTO metadata (key, value) VALUES ('device_verifier', ?)",
-		encodeBytes(verifier))
-	if err != nil {
-		return fmt.Errorf("failed to save verifier: %w", err)
-	}
 
-	// 保存默认设置
-	return d.saveSettingsInternal(DefaultSettings())
+	d.masterKey = key
+	return nil
 }
 
 // SetPassword 设置密码保护
@@ -190,16 +188,24 @@ func (d *Database) SetPassword(password string) error {
 	d.mu.Lock()
 	defer d.mu.Unlock()
 
-	// 获取当前数据
-	accounts, err := d.getAllAccountsInternal()
+	// 生成新盐值
+	salt, err := GenerateSalt()
 	if err != nil {
 		return err
 	}
 
-	settings, err := d.getSettingsInternal()
+	// 使用目标参数派生新主密钥
+	params, err := d.targetKDFParamsLocked()
 	if err != nil {
-		settings = DefaultSettings()
+		return err
 	}
+	return d.rekeyLocked(params.DeriveKey(password, salt), salt, &params)
+}
+
+// RemovePassword 移除密码保护
+func (d *Database) RemovePassword() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
 
 	// 生成新盐值
 	salt, err := GenerateSalt()
@@ -207,113 +213,105 @@ func (d *Database) SetPassword(password string) error {
 		return err
 	}
 
-	// 派生新主密钥
-	newMasterKey := DeriveKey(password, salt)
+	// 使用设备密钥
+	return d.rekeyLocked(GetDeviceKey(), salt, nil)
+}
 
-	// 保存新盐值（Base64 编码）
-	_, err = d.db.Exec("INSERT OR REPLACE INTO metadata (key, value) VALUES ('salt', ?)",
-		encodeBytes(salt))
-	if err != nil {
-		return fmt.Errorf("failed to save salt: %w", err)
-	}
+// rekeyLocked 用新主密钥重新加密全部数据，调用方需持有锁
+// kdf 为派生新密钥使用的参数，nil 表示使用设备密钥（无密码）
+// 盐值、KDF 参数、验证器和设置在同一事务中替换，账户按 id 原地重新加密以保留创建时间，任一步失败整体回滚，主密钥只在提交成功后更新
+func (d *Database) rekeyLocked(newMasterKey, salt []byte, kdf *KDFParams) error {
+	passwordEnabled := kdf != nil
 
-	// 创建密码验证器
-	verifier, err := Encrypt([]byte(verifierConstant), newMasterKey)
-	if err != nil {
+	if err := d.ensureUnlocked(); err != nil {
 		return err
 	}
-	_, err = d.db.Exec("INSERT OR REPLACE INTO metadata (key, value) VALUES ('password_verifier', ?)",
-		encodeBytes(verifier))
-	if err != nil {
-		return fmt.Errorf("failed to save verifier: %w", err)
-	}
 
-	// 删除设备验证器
-	d.db.Exec("DELETE FROM metadata WHERE key = 'device_verifier'")
-
-	// 更新主密钥
-	d.masterKey = newMasterKey
-
-	// 重新加密所有数据
-	settings.PasswordEnabled = true
-	if err := d.saveSettingsInternal(settings); err != nil {
+	// 重新加密前先生成快照，中途失败时可以恢复
+	if _, err := d.snapshotLocked(SnapshotReasonPassword); err != nil {
 		return err
 	}
 
-	// 清空并重新保存账户
-	d.db.Exec("DELETE FROM accounts")
-	for _, acc := range accounts {
-		if err := d.saveAccountInternal(acc); err != nil {
-			return err
-		}
-	}
-
-	return nil
-}
-
-// RemovePassword 移除密码保护
-func (d *Database) RemovePassword() error {
-	d.mu.Lock()
-	defer d.mu.Unlock()
-
-	// 获取当前数据
+	// 获取当前数据，有无法解密的账户时拒绝继续，避免重新加密后丢失
 	accounts, err := d.getAllAccountsInternal()
 	if err != nil {
 		return err
 	}
+	var total int
+	if err := d.db.QueryRow("SELECT COUNT(*) FROM accounts").Scan(&total); err != nil {
+		return fmt.Errorf("failed to count accounts: %w", err)
+	}
+	if total != len(accounts) {
+		return fmt.Errorf("%d accounts cannot be decrypted", total-len(accounts))
+	}
 
 	settings, err := d.getSettingsInternal()
 	if err != nil {
 		settings = DefaultSettings()
 	}
+	settings.PasswordEnabled = passwordEnabled
 
-	// 使用设备密钥
-	newMasterKey := GetDeviceKey()
+	verifierKey, staleVerifierKey := "device_verifier", "password_verifier"
+	if passwordEnabled {
+		verifierKey, staleVerifierKey = staleVerifierKey, verifierKey
+	}
 
-	// 生成新盐值
-	salt, err := GenerateSalt()
+	// 创建验证器
+	verifier, err := Encrypt([]byte(verifierConstant), newMasterKey)
 	if err != nil {
 		return err
 	}
 
-	// 保存盐值（Base64 编码）
-	_, err = d.db.Exec("INSERT OR REPLACE INTO metadata (key, value) VALUES ('salt', ?)",
-		encodeBytes(salt))
-	if err != nil {
-		return fmt.Errorf("failed to save salt: %w", err)
-	}
+	err = d.withTx(func(tx *sql.Tx) error {
+		// 保存新盐值（Base64 编码）
+		if _, err := tx.Exec("INSERT OR REPLACE INTO metadata (key, value) VALUES ('salt', ?)",
+			encodeBytes(salt)); err != nil {
+			return fmt.Errorf("failed to save salt: %w", err)
+		}
+		if _, err := tx.Exec("INSERT OR REPLACE INTO metadata (key, value) VALUES (?, ?)",
+			verifierKey, encodeBytes(verifier)); err != nil {
+			return fmt.Errorf("failed to save verifier: %w", err)
+		}
+		if _, err := tx.Exec("DELETE FROM metadata WHERE key = ?", staleVerifierKey); err != nil {
+			return fmt.Errorf("failed to delete verifier: %w", err)
+		}
+		if err := saveKDF(tx, kdf); err != nil {
+			return err
+		}
 
-	// 创建设备验证器
-	verifier, err := Encrypt([]byte(verifierConstant), newMasterKey)
+		// 重新加密设置和账户
+		if err := saveSettings(tx, newMasterKey, settings); err != nil {
+			return err
+		}
+		for _, acc := range accounts {
+			if _, err := reencryptAccount(tx, newMasterKey, acc); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 	if err != nil {
 		return err
 	}
-	_, err = d.db.Exec("INSERT OR REPLACE INTO metadata (key, value) VALUES ('device_verifier', ?)",
-		encodeBytes(verifier))
-	if err != nil {
-		return fmt.Errorf("failed to save verifier: %w", err)
-	}
-
-	// 删除密码验证器
-	d.db.Exec("DELETE FROM metadata WHERE key = 'password_verifier'")
 
 	// 更新主密钥
 	d.masterKey = newMasterKey
+	return nil
+}
 
-	// 重新加密所有数据
-	settings.PasswordEnabled = false
-	if err := d.saveSettingsInternal(settings); err != nil {
+// withTx 在事务中执行 fn，fn 返回错误时回滚
+func (d *Database) withTx(fn func(tx *sql.Tx) error) error {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	if err := fn(tx); err != nil {
+		tx.Rollback()
 		return err
 	}
-
-	// 清空并重新保存账户
-	d.db.Exec("DELETE FROM accounts")
-	for _, acc := range accounts {
-		if err := d.saveAccountInternal(acc); err != nil {
-			return err
-		}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
 	}
-
 	return nil
 }
 
@@ -334,8 +332,12 @@ func (d *Database) Unlock(password string) error {
 		return fmt.Errorf("failed to decode salt: %w", err)
 	}
 
-	// 派生密钥
-	key := DeriveKey(password, salt)
+	// 使用保存的参数派生密钥
+	params, err := d.kdfParamsLocked()
+	if err != nil {
+		return err
+	}
+	key := params.DeriveKey(password, salt)
 
 	// 获取验证器
 	var verifierEncoded string
@@ -355,6 +357,9 @@ func (d *Database) Unlock(password string) error {
 	}
 
 	d.masterKey = key
+
+	// 参数已调整时顺带重新派生密钥，失败时保持旧密钥，下次解锁再试
+	d.upgradeKDFLocked(password)
 	return nil
 }
 
@@ -405,8 +410,12 @@ func (d *Database) VerifyPassword(password string) bool {
 		return false
 	}
 
-	// 派生密钥
-	key := DeriveKey(password, salt)
+	// 使用保存的参数派生密钥
+	params, err := d.kdfParamsLocked()
+	if err != nil {
+		return false
+	}
+	key := params.DeriveKey(password, salt)
 
 	// 获取验证器
 	var verifierEncoded string
@@ -477,48 +486,84 @@ func (d *Database) reinitializeWithDeviceKey() error {
 	}
 
 	// 使用设备密钥
-	d.masterKey = GetDeviceKey()
-
-	// 保存盐值
-	_, err = d.db.Exec("INSERT OR REPLACE INTO metadata (key, value) VALUES ('salt', ?)",
-		encodeBytes(salt))
-	if err != nil {
-		return fmt.Errorf("failed to save salt: %w", err)
-	}
+	key := GetDeviceKey()
 
 	// 创建新的设备验证器
-	verifier, err := Encrypt([]byte(verifierConstant), d.masterKey)
+	verifier, err := Encrypt([]byte(verifierConstant), key)
 	if err != nil {
 		return err
 	}
-	_, err = d.db.Exec("INSERT OR REPLACE INTO metadata (key, value) VALUES ('device_verifier', ?)",
-		encodeBytes(verifier))
+
+	err = d.withTx(func(tx *sql.Tx) error {
+		// 保存盐值
+		if _, err := tx.Exec("INSERT OR REPLACE INTO metadata (key, value) VALUES ('salt', ?)",
+			encodeBytes(salt)); err != nil {
+			return fmt.Errorf("failed to save salt: %w", err)
+		}
+		if _, err := tx.Exec("INSERT OR REPLACE INTO metadata (key, value) VALUES ('device_verifier', ?)",
+			encodeBytes(verifier)); err != nil {
+			return fmt.Errorf("failed to save verifier: %w", err)
+		}
+		return nil
+	})
 	if err != nil {
-		return fmt.Errorf("failed to save verifier: %w", err)
+		return err
 	}
 
+	d.masterKey = key
 	return nil
 }
 
 // === 账户操作 ===
 
 func (d *Database) saveAccountInternal(acc Account) error {
+	return saveAccount(d.db, d.masterKey, acc)
+}
+
+// saveAccount 使用指定密钥加密并写入账户
+func saveAccount(ex execer, key []byte, acc Account) error {
 	data, err := json.Marshal(acc)
 	if err != nil {
 		return fmt.Errorf("failed to marshal account: %w", err)
 	}
 
-	encrypted, err := Encrypt(data, d.masterKey)
+	encrypted, err := Encrypt(data, key)
 	if err != nil {
 		return err
 	}
 
-	// 使用 Base64 编码存储
-	_, err = d.db.Exec("INSERT OR REPLACE INTO accounts (id, data) VALUES (?, ?)",
-		acc.ID, encodeBytes(encrypted))
+	// 使用 Base64 编码存储，更新已有账户时保留创建时间
+	now := time.Now().Unix()
+	_, err = ex.Exec(`INSERT INTO accounts (id, data, created_at, updated_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET data = excluded.data, updated_at = excluded.updated_at`,
+		acc.ID, encodeBytes(encrypted), now, now)
 	return err
 }
 
+// reencryptAccount 用 key 原地重写已有账户的密文，不改变创建和更新时间
+// 账户不存在时返回 false
+func reencryptAccount(ex execer, key []byte, acc Account) (bool, error) {
+	data, err := json.Marshal(acc)
+	if err != nil {
+		return false, fmt.Errorf("failed to marshal account: %w", err)
+	}
+
+	encrypted, err := Encrypt(data, key)
+	if err != nil {
+		return false, err
+	}
+
+	res, err := ex.Exec("UPDATE accounts SET data = ? WHERE id = ?", encodeBytes(encrypted), acc.ID)
+	if err != nil {
+		return false, fmt.Errorf("failed to update account: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to update account: %w", err)
+	}
+	return n > 0, nil
+}
+
 // SaveAccount 保存账户
 func (d *Database) SaveAccount(acc Account) error {
 	d.mu.Lock()
@@ -570,7 +615,7 @@ func (d *Database) GetAccount(id string) (*Account, error) {
 }
 
 func (d *Database) getAllAccountsInternal() ([]Account, error) {
-	rows, err := d.db.Query("SELECT data FROM accounts")
+	rows, err := d.db.Query("SELECT data FROM accounts ORDER BY created_at, rowid")
 	if err != nil {
 		return nil, err
 	}
@@ -621,15 +666,132 @@ func (d *Database) DeleteAccount(id string) error {
 	d.mu.Lock()
 	defer d.mu.Unlock()
 
+	if _, err := d.snapshotLocked(SnapshotReasonDelete); err != nil {
+		return err
+	}
+
 	_, err := d.db.Exec("DELETE FROM accounts WHERE id = ?", id)
 	return err
 }
 
+// DeleteAccounts 批量删除账户，只生成一次快照，返回实际删除的数量
+func (d *Database) DeleteAccounts(ids []string) (int, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	if _, err := d.snapshotLocked(SnapshotReasonDelete); err != nil {
+		return 0, err
+	}
+
+	deleted := 0
+	err := d.withTx(func(tx *sql.Tx) error {
+		for _, id := range ids {
+			res, err := tx.Exec("DELETE FROM accounts WHERE id = ?", id)
+			if err != nil {
+				return fmt.Errorf("failed to delete account: %w", err)
+			}
+			if n, err := res.RowsAffected(); err == nil {
+				deleted += int(n)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return deleted, nil
+}
+
+// MergeAccountInto 在同一事务中保存合并后的账户并删除被合并的重复账户，返回实际删除的数量
+// 执行前生成快照；任一步失败整体回滚，不会出现元数据已合并而重复账户仍在的状态
+func (d *Database) MergeAccountInto(merged Account, removeIDs []string) (int, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	// 确保已解锁
+	if err := d.ensureUnlocked(); err != nil {
+		return 0, err
+	}
+	if _, err := d.snapshotLocked(SnapshotReasonDelete); err != nil {
+		return 0, err
+	}
+
+	deleted := 0
+	err := d.withTx(func(tx *sql.Tx) error {
+		if err := saveAccount(tx, d.masterKey, merged); err != nil {
+			return fmt.Errorf("failed to save account: %w", err)
+		}
+		for _, id := range removeIDs {
+			if id == merged.ID {
+				continue
+			}
+			res, err := tx.Exec("DELETE FROM accounts WHERE id = ?", id)
+			if err != nil {
+				return fmt.Errorf("failed to delete account: %w", err)
+			}
+			if n, err := res.RowsAffected(); err == nil {
+				deleted += int(n)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return deleted, nil
+}
+
+// UpdateAccountsGroup 在同一事务中修改多个账户的分组，返回实际修改的数量
+func (d *Database) UpdateAccountsGroup(ids []string, group string) (int, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	// 确保已解锁
+	if err := d.ensureUnlocked(); err != nil {
+		return 0, err
+	}
+
+	wanted := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		wanted[id] = true
+	}
+	accounts, err := d.getAllAccountsInternal()
+	if err != nil {
+		return 0, err
+	}
+
+	updated := 0
+	err = d.withTx(func(tx *sql.Tx) error {
+		for _, acc := range accounts {
+			if !wanted[acc.ID] {
+				continue
+			}
+			acc.Group = group
+			if err := saveAccount(tx, d.masterKey, acc); err != nil {
+				return err
+			}
+			updated++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return updated, nil
+}
+
 // DeleteAllAccounts 删除所有账户
 func (d *Database) DeleteAllAccounts() error {
 	d.mu.Lock()
 	defer d.mu.Unlock()
 
+	if _, err := d.snapshotLocked(SnapshotReasonDeleteAll); err != nil {
+		return err
+	}
+
 	_, err := d.db.Exec("DELETE FROM accounts")
 	return err
 }
@@ -637,18 +799,23 @@ func (d *Database) DeleteAllAccounts() error {
 // === 设置操作 ===
 
 func (d *Database) saveSettingsInternal(s Settings) error {
+	return saveSettings(d.db, d.masterKey, s)
+}
+
+// saveSettings 使用指定密钥加密并写入设置
+func saveSettings(ex execer, key []byte, s Settings) error {
 	data, err := json.Marshal(s)
 	if err != nil {
 		return fmt.Errorf("failed to marshal settings: %w", err)
 	}
 
-	encrypted, err := Encrypt(data, d.masterKey)
+	encrypted, err := Encrypt(data, key)
 	if err != nil {
 		return err
 	}
 
 	// 使用 Base64 编码存储
-	_, err = d.db.Exec("INSERT OR REPLACE INTO settings (key, value) VALUES ('main', ?)",
+	_, err = ex.Exec("INSERT OR REPLACE INTO settings (key, value) VALUES ('main', ?)",
 		encodeBytes(encrypted))
 	return err
 }
@@ -0,0 +1,105 @@
+package storage
+
+import "testing"
+
+// newTestDatabase 在临时目录中创建并初始化数据库（无密码）
+func newTestDatabase(t *testing.T) *Database {
+	t.Helper()
+	d, err := NewDatabase(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { d.Close() })
+	if err := d.Initialize(); err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
+
+// setCreatedAt 将账户的创建时间改为 ts，用于检查重写后是否保留
+func setCreatedAt(t *testing.T, d *Database, id string, ts int64) {
+	t.Helper()
+	if _, err := d.db.Exec("UPDATE accounts SET created_at = ? WHERE id = ?", ts, id); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// createdAt 返回账户的创建时间
+func createdAt(t *testing.T, d *Database, id string) int64 {
+	t.Helper()
+	var ts int64
+	if err := d.db.QueryRow("SELECT created_at FROM accounts WHERE id = ?", id).Scan(&ts); err != nil {
+		t.Fatal(err)
+	}
+	return ts
+}
+
+func TestMergeAccountInto(t *testing.T) {
+	d := newTestDatabase(t)
+	for _, acc := range []Account{
+		{ID: "keep", Issuer: "Example", Name: "alice", Secret: "JBSWY3DPEHPK3PXP"},
+		{ID: "dup", Issuer: "Example", Name: "alice", Secret: "JBSWY3DPEHPK3PXP", Group: "Work"},
+		{ID: "other", Issuer: "Bank", Name: "bob", Secret: "GEZDGNBVGY3TQOJQ"},
+	} {
+		if err := d.SaveAccount(acc); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	merged := Account{ID: "keep", Issuer: "Example", Name: "alice", Secret: "JBSWY3DPEHPK3PXP", Group: "Work"}
+	count, err := d.MergeAccountInto(merged, []string{"dup", "keep"})
+	if err != nil {
+		t.Fatalf("MergeAccountInto: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("deleted %d accounts, want 1", count)
+	}
+
+	accounts, err := d.GetAllAccounts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(accounts) != 2 || accounts[0].ID != "keep" || accounts[0].Group != "Work" || accounts[1].ID != "other" {
+		t.Errorf("accounts after merge = %+v", accounts)
+	}
+
+	snapshots, err := d.ListSnapshots()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(snapshots) == 0 {
+		t.Error("expected a snapshot before merging")
+	}
+}
+
+func TestSetPasswordPreservesCreatedAt(t *testing.T) {
+	d := newTestDatabase(t)
+	for _, acc := range []Account{
+		{ID: "new", Issuer: "Example", Name: "alice", Secret: "JBSWY3DPEHPK3PXP"},
+		{ID: "old", Issuer: "Bank", Name: "bob", Secret: "GEZDGNBVGY3TQOJQ"},
+	} {
+		if err := d.SaveAccount(acc); err != nil {
+			t.Fatal(err)
+		}
+	}
+	setCreatedAt(t, d, "new", 2000)
+	setCreatedAt(t, d, "old", 1000)
+
+	if err := d.SetPassword("correct horse"); err != nil {
+		t.Fatalf("SetPassword: %v", err)
+	}
+	if got := createdAt(t, d, "old"); got != 1000 {
+		t.Errorf("created_at of old = %d, want 1000", got)
+	}
+	if got := createdAt(t, d, "new"); got != 2000 {
+		t.Errorf("created_at of new = %d, want 2000", got)
+	}
+
+	accounts, err := d.GetAllAccounts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(accounts) != 2 || accounts[0].ID != "old" || accounts[1].ID != "new" {
+		t.Errorf("accounts after SetPassword = %+v", accounts)
+	}
+}
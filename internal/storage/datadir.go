@@ -0,0 +1,157 @@
+package storage
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+// 数据目录按以下优先级确定：
+//  1. --data-dir 参数
+//  2. 环境变量 GOOGLE_AUTHENTICATOR_DATA_DIR
+//  3. 便携模式（--portable 参数或可执行文件旁存在 .portable 标记文件）：可执行文件目录下的 data
+//  4. 用户数据目录：Linux 为 $XDG_DATA_HOME/google-authenticator（默认 ~/.local/share），
+//     Windows 为 %AppData%，macOS 为 ~/Library/Application Support
+const (
+	AppDirName         = "google-authenticator"
+	DataDirEnv         = "GOOGLE_AUTHENTICATOR_DATA_DIR"
+	PortableMarkerName = ".portable"
+
+	legacyDataDirName    = "data"
+	migratedLegacySuffix = ".migrated"
+)
+
+// DataDirOptions 命令行指定的数据目录选项
+type DataDirOptions struct {
+	DataDir  string // --data-dir
+	Portable bool   // --portable
+}
+
+// ResolveDataDir 确定数据目录（不创建目录）
+func ResolveDataDir(opts DataDirOptions) (string, error) {
+	if opts.DataDir != "" {
+		return filepath.Abs(opts.DataDir)
+	}
+	if dir := os.Getenv(DataDirEnv); dir != "" {
+		return filepath.Abs(dir)
+	}
+
+	execDir, err := executableDir()
+	if err != nil {
+		return "", err
+	}
+	if opts.Portable {
+		return filepath.Join(execDir, legacyDataDirName), nil
+	}
+	if _, err := os.Stat(filepath.Join(execDir, PortableMarkerName)); err == nil {
+		return filepath.Join(execDir, legacyDataDirName), nil
+	}
+
+	base, err := userDataHome()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(base, AppDirName), nil
+}
+
+// IsPortableDir 判断数据目录是否为便携模式目录
+func IsPortableDir(dataDir string) bool {
+	execDir, err := executableDir()
+	if err != nil {
+		return false
+	}
+	return filepath.Clean(dataDir) == filepath.Join(execDir, legacyDataDirName)
+}
+
+// userDataHome 返回当前用户的应用数据根目录
+func userDataHome() (string, error) {
+	if runtime.GOOS == "windows" || runtime.GOOS == "darwin" {
+		return os.UserConfigDir()
+	}
+
+	// XDG 规范要求路径为绝对路径，否则忽略
+	if dir := os.Getenv("XDG_DATA_HOME"); filepath.IsAbs(dir) {
+		return dir, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return filepath.Join(home, ".local", "share"), nil
+}
+
+// executableDir 返回可执行文件所在目录
+func executableDir() (string, error) {
+	execPath, err := os.Executable()
+	if err != nil {
+		return "", fmt.Errorf("failed to get executable path: %w", err)
+	}
+	return filepath.Dir(execPath), nil
+}
+
+// MigrateLegacyDataDir 将旧版本放在可执行文件旁 data 目录中的数据库复制到新的数据目录
+// 仅在新目录还没有数据库时进行；复制完成后旧数据库重命名为 .migrated，避免重复迁移
+// 返回是否发生了迁移
+func MigrateLegacyDataDir(dataDir string) (bool, error) {
+	execDir, err := executableDir()
+	if err != nil {
+		return false, err
+	}
+	legacyDir := filepath.Join(execDir, legacyDataDirName)
+	if filepath.Clean(dataDir) == legacyDir {
+		return false, nil
+	}
+
+	legacyDB := filepath.Join(legacyDir, dbFileName)
+	if _, err := os.Stat(legacyDB); err != nil {
+		return false, nil
+	}
+	newDB := filepath.Join(dataDir, dbFileName)
+	if _, err := os.Stat(newDB); err == nil {
+		return false, nil
+	}
+
+	if err := os.MkdirAll(dataDir, 0700); err != nil {
+		return false, fmt.Errorf("failed to create data directory: %w", err)
+	}
+	if err := copyFile(legacyDB, newDB); err != nil {
+		os.Remove(newDB)
+		return false, fmt.Errorf("failed to migrate database: %w", err)
+	}
+
+	// 快照一并复制，失败不影响数据库迁移
+	if entries, err := os.ReadDir(filepath.Join(legacyDir, snapshotDirName)); err == nil {
+		snapshotDir := filepath.Join(dataDir, snapshotDirName)
+		if err := os.MkdirAll(snapshotDir, 0700); err == nil {
+			for _, e := range entries {
+				if !e.IsDir() {
+					copyFile(filepath.Join(legacyDir, snapshotDirName, e.Name()), filepath.Join(snapshotDir, e.Name()))
+				}
+			}
+		}
+	}
+
+	os.Rename(legacyDB, legacyDB+migratedLegacySuffix)
+	return true, nil
+}
+
+// copyFile 复制文件，目标文件权限为 0600
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
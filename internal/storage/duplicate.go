@@ -0,0 +1,195 @@
+package storage
+
+import (
+	"encoding/base32"
+	"strings"
+)
+
+// MergePolicy 导入时遇到重复账户的处理策略
+type MergePolicy string
+
+const (
+	PolicySkip      MergePolicy = "skip"      // 保留已有账户，忽略导入项
+	PolicyOverwrite MergePolicy = "overwrite" // 用导入项覆盖已有账户（保留 ID）
+	PolicyKeepBoth  MergePolicy = "keep_both" // 作为新账户另存
+	PolicyMerge     MergePolicy = "merge"     // 保留已有密钥参数，补全分组等元数据
+)
+
+// ParseMergePolicy 解析策略字符串，未知值按 PolicySkip 处理
+func ParseMergePolicy(s string) MergePolicy {
+	switch MergePolicy(strings.ToLower(strings.TrimSpace(s))) {
+	case PolicyOverwrite:
+		return PolicyOverwrite
+	case PolicyKeepBoth:
+		return PolicyKeepBoth
+	case PolicyMerge:
+		return PolicyMerge
+	default:
+		return PolicySkip
+	}
+}
+
+// MatchKind 重复账户的判定依据
+type MatchKind string
+
+const (
+	MatchNone     MatchKind = ""
+	MatchSecret   MatchKind = "secret"      // 密钥相同
+	MatchIdentity MatchKind = "issuer_name" // 发行者与账户名相同
+)
+
+// DuplicateGroup 一组互相重复的账户
+type DuplicateGroup struct {
+	Match    MatchKind `json:"match"`
+	Accounts []Account `json:"accounts"`
+}
+
+// secretKey 返回用于比较的密钥标识，忽略大小写、空格与填充
+func secretKey(secret string) string {
+	normalized := strings.ToUpper(strings.NewReplacer(" ", "", "-", "", "=", "").Replace(secret))
+	if raw, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(normalized); err == nil {
+		return string(raw)
+	}
+	return normalized
+}
+
+// SameSecret 判断两个账户的密钥是否相同（忽略大小写、空格与填充）
+func SameSecret(a, b Account) bool {
+	key := secretKey(a.Secret)
+	return key != "" && key == secretKey(b.Secret)
+}
+
+// identityKey 返回用于比较的 发行者+账户名 标识，空账户名不参与比较
+func identityKey(acc Account) string {
+	name := strings.ToLower(strings.TrimSpace(acc.Name))
+	if name == "" {
+		return ""
+	}
+	return strings.ToLower(strings.TrimSpace(acc.Issuer)) + "\x00" + name
+}
+
+// FindDuplicate 在 existing 中查找与 acc 重复的账户，密钥相同优先于发行者+账户名相同
+func FindDuplicate(existing []Account, acc Account) (*Account, MatchKind) {
+	secret := secretKey(acc.Secret)
+	for i := range existing {
+		if existing[i].ID != acc.ID && secret != "" && secretKey(existing[i].Secret) == secret {
+			return &existing[i], MatchSecret
+		}
+	}
+
+	identity := identityKey(acc)
+	if identity == "" {
+		return nil, MatchNone
+	}
+	for i := range existing {
+		if existing[i].ID != acc.ID && identityKey(existing[i]) == identity {
+			return &existing[i], MatchIdentity
+		}
+	}
+
+	return nil, MatchNone
+}
+
+// EffectivePolicy 返回对某个重复项实际应采用的策略
+// 仅发行者+账户名相同而密钥不同时，通常是账户重新绑定过，合并或覆盖都会丢失其中一个密钥，
+// 此时 PolicyMerge 与 PolicyOverwrite 退化为 PolicyKeepBoth
+func EffectivePolicy(existing, incoming Account, match MatchKind, policy MergePolicy) MergePolicy {
+	if match == MatchIdentity && !SameSecret(existing, incoming) &&
+		(policy == PolicyMerge || policy == PolicyOverwrite) {
+		return PolicyKeepBoth
+	}
+	return policy
+}
+
+// MergeAccounts 按策略合并已有账户与导入账户，返回应写入的账户（ID 沿用已有账户）
+// 仅适用于 PolicyOverwrite 与 PolicyMerge，调用前应先经 EffectivePolicy 排除密钥不同的账户
+func MergeAccounts(existing, incoming Account, policy MergePolicy) Account {
+	merged := existing
+
+	switch policy {
+	case PolicyOverwrite:
+		merged = incoming
+		merged.ID = existing.ID
+		// 导入项缺失的元数据沿用已有值
+		if merged.Group == "" {
+			merged.Group = existing.Group
+		}
+		if merged.Issuer == "" {
+			merged.Issuer = existing.Issuer
+		}
+		if merged.Name == "" {
+			merged.Name = existing.Name
+		}
+		if merged.Note == "" {
+			merged.Note = existing.Note
+		}
+		if merged.Icon == "" {
+			merged.Icon = existing.Icon
+		}
+	case PolicyMerge:
+		if merged.Group == "" {
+			merged.Group = incoming.Group
+		}
+		if merged.Issuer == "" {
+			merged.Issuer = incoming.Issuer
+		}
+		if merged.Name == "" {
+			merged.Name = incoming.Name
+		}
+		if merged.Note == "" {
+			merged.Note = incoming.Note
+		}
+		if merged.Icon == "" {
+			merged.Icon = incoming.Icon
+		}
+	}
+
+	// 同一密钥的 HOTP 计数器取较大值，避免重复使用验证码
+	if secretKey(existing.Secret) == secretKey(incoming.Secret) && incoming.Counter > merged.Counter {
+		merged.Counter = incoming.Counter
+	}
+
+	return merged
+}
+
+// FindDuplicateGroups 扫描账户列表，按密钥或 发行者+账户名 分组返回重复账户
+// 每个账户最多出现在一个分组中，密钥相同的分组优先
+func FindDuplicateGroups(accounts []Account) []DuplicateGroup {
+	var groups []DuplicateGroup
+	used := make(map[string]bool)
+
+	collect := func(match MatchKind, keyOf func(Account) string) {
+		index := make(map[string]int)
+		var pending []DuplicateGroup
+		for _, acc := range accounts {
+			if used[acc.ID] {
+				continue
+			}
+			key := keyOf(acc)
+			if key == "" {
+				continue
+			}
+			if i, ok := index[key]; ok {
+				pending[i].Accounts = append(pending[i].Accounts, acc)
+				continue
+			}
+			index[key] = len(pending)
+			pending = append(pending, DuplicateGroup{Match: match, Accounts: []Account{acc}})
+		}
+
+		for _, g := range pending {
+			if len(g.Accounts) < 2 {
+				continue
+			}
+			for _, acc := range g.Accounts {
+				used[acc.ID] = true
+			}
+			groups = append(groups, g)
+		}
+	}
+
+	collect(MatchSecret, func(acc Account) string { return secretKey(acc.Secret) })
+	collect(MatchIdentity, identityKey)
+
+	return groups
+}
@@ -0,0 +1,35 @@
+package storage
+
+import "testing"
+
+func TestEffectivePolicy(t *testing.T) {
+	existing := Account{ID: "1", Issuer: "Example", Name: "alice", Secret: "JBSWY3DPEHPK3PXP"}
+	sameSecret := Account{Issuer: "Other", Name: "alice", Secret: "jbsw y3dp ehpk 3pxp"}
+	reEnrolled := Account{Issuer: "Example", Name: "alice", Secret: "GEZDGNBVGY3TQOJQ"}
+
+	if dup, match := FindDuplicate([]Account{existing}, sameSecret); dup == nil || match != MatchSecret {
+		t.Fatalf("FindDuplicate(same secret) = %v, %q", dup, match)
+	}
+	if dup, match := FindDuplicate([]Account{existing}, reEnrolled); dup == nil || match != MatchIdentity {
+		t.Fatalf("FindDuplicate(same identity) = %v, %q", dup, match)
+	}
+
+	tests := []struct {
+		incoming Account
+		match    MatchKind
+		policy   MergePolicy
+		want     MergePolicy
+	}{
+		{sameSecret, MatchSecret, PolicyMerge, PolicyMerge},
+		{sameSecret, MatchSecret, PolicyOverwrite, PolicyOverwrite},
+		{reEnrolled, MatchIdentity, PolicyMerge, PolicyKeepBoth},
+		{reEnrolled, MatchIdentity, PolicyOverwrite, PolicyKeepBoth},
+		{reEnrolled, MatchIdentity, PolicySkip, PolicySkip},
+		{reEnrolled, MatchIdentity, PolicyKeepBoth, PolicyKeepBoth},
+	}
+	for _, tt := range tests {
+		if got := EffectivePolicy(existing, tt.incoming, tt.match, tt.policy); got != tt.want {
+			t.Errorf("EffectivePolicy(%s, %s) = %s, want %s", tt.match, tt.policy, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,195 @@
+package storage
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// 密码派生参数与盐值一起保存在 metadata 中：
+//   - kdf：当前密钥使用的算法和参数，缺失时为引入该记录之前的默认参数
+//   - kdf_target：用户设定的目标参数，缺失时为 DefaultKDFParams
+//
+// 两者不一致时，下一次成功解锁会用目标参数重新派生密钥并重新加密全部数据，
+// 因此调整默认参数或重新校准都不会使已有数据库无法解锁。
+const (
+	KDFArgon2id = "argon2id"
+
+	kdfMetaKey       = "kdf"
+	kdfTargetMetaKey = "kdf_target"
+
+	// 校准得到的迭代次数上限，与 Validate 一致
+	calibrationMaxTime = 64
+)
+
+// storedKDF metadata 中保存的 KDF 记录
+type storedKDF struct {
+	Algorithm string `json:"algorithm"`
+	KDFParams
+}
+
+// legacyKDFParams 引入 kdf 记录之前所有数据库使用的参数
+func legacyKDFParams() KDFParams {
+	return KDFParams{Time: 1, Memory: 64 * 1024, Threads: 4}
+}
+
+// marshalKDF 序列化 KDF 记录
+func marshalKDF(p KDFParams) (string, error) {
+	data, err := json.Marshal(storedKDF{Algorithm: KDFArgon2id, KDFParams: p})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal kdf params: %w", err)
+	}
+	return string(data), nil
+}
+
+// readKDF 读取 metadata 中的 KDF 记录，ok 为 false 表示记录不存在
+func readKDF(q querier, key string) (p KDFParams, ok bool, err error) {
+	var value string
+	err = q.QueryRow("SELECT value FROM metadata WHERE key = ?", key).Scan(&value)
+	if err == sql.ErrNoRows {
+		return KDFParams{}, false, nil
+	}
+	if err != nil {
+		return KDFParams{}, false, err
+	}
+
+	var stored storedKDF
+	if err := json.Unmarshal([]byte(value), &stored); err != nil {
+		return KDFParams{}, false, fmt.Errorf("invalid kdf params: %w", err)
+	}
+	if stored.Algorithm != KDFArgon2id {
+		return KDFParams{}, false, fmt.Errorf("unsupported kdf algorithm: %s", stored.Algorithm)
+	}
+	if err := stored.Validate(); err != nil {
+		return KDFParams{}, false, err
+	}
+	return stored.KDFParams, true, nil
+}
+
+// saveKDF 保存当前密钥使用的参数，nil 表示不使用密码派生（设备密钥）
+func saveKDF(ex execer, p *KDFParams) error {
+	if p == nil {
+		if _, err := ex.Exec("DELETE FROM metadata WHERE key = ?", kdfMetaKey); err != nil {
+			return fmt.Errorf("failed to delete kdf params: %w", err)
+		}
+		return nil
+	}
+
+	value, err := marshalKDF(*p)
+	if err != nil {
+		return err
+	}
+	if _, err := ex.Exec("INSERT OR REPLACE INTO metadata (key, value) VALUES (?, ?)", kdfMetaKey, value); err != nil {
+		return fmt.Errorf("failed to save kdf params: %w", err)
+	}
+	return nil
+}
+
+// kdfParamsLocked 当前密钥使用的参数
+func (d *Database) kdfParamsLocked() (KDFParams, error) {
+	p, ok, err := readKDF(d.db, kdfMetaKey)
+	if err != nil {
+		return KDFParams{}, err
+	}
+	if !ok {
+		return legacyKDFParams(), nil
+	}
+	return p, nil
+}
+
+// targetKDFParamsLocked 设置或更新密码时使用的参数
+func (d *Database) targetKDFParamsLocked() (KDFParams, error) {
+	p, ok, err := readKDF(d.db, kdfTargetMetaKey)
+	if err != nil {
+		return KDFParams{}, err
+	}
+	if !ok {
+		return DefaultKDFParams(), nil
+	}
+	return p, nil
+}
+
+// KDFParams 获取当前密钥使用的参数
+func (d *Database) KDFParams() (KDFParams, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return d.kdfParamsLocked()
+}
+
+// TargetKDFParams 获取目标参数
+func (d *Database) TargetKDFParams() (KDFParams, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return d.targetKDFParamsLocked()
+}
+
+// SetTargetKDFParams 设置目标参数，已启用密码时在下一次解锁时生效
+func (d *Database) SetTargetKDFParams(p KDFParams) error {
+	if err := p.Validate(); err != nil {
+		return err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	// 确保已解锁
+	if err := d.ensureUnlocked(); err != nil {
+		return err
+	}
+
+	value, err := marshalKDF(p)
+	if err != nil {
+		return err
+	}
+	_, err = d.db.Exec("INSERT OR REPLACE INTO metadata (key, value) VALUES (?, ?)", kdfTargetMetaKey, value)
+	if err != nil {
+		return fmt.Errorf("failed to save kdf params: %w", err)
+	}
+	return nil
+}
+
+// upgradeKDFLocked 当前参数与目标参数不一致时用目标参数重新派生密钥，调用方需持有锁且已解锁
+func (d *Database) upgradeKDFLocked(password string) error {
+	current, err := d.kdfParamsLocked()
+	if err != nil {
+		return err
+	}
+	target, err := d.targetKDFParamsLocked()
+	if err != nil {
+		return err
+	}
+	if current == target {
+		return nil
+	}
+
+	salt, err := GenerateSalt()
+	if err != nil {
+		return err
+	}
+	return d.rekeyLocked(target.DeriveKey(password, salt), salt, &target)
+}
+
+// CalibrateKDFParams 在本机测量派生耗时，选择使解锁时间接近 target 的参数
+// 内存和线程数保持默认值，只增加迭代次数，结果不会弱于 DefaultKDFParams
+func CalibrateKDFParams(target time.Duration) KDFParams {
+	p := DefaultKDFParams()
+
+	salt := make([]byte, saltLen)
+	rand.Read(salt)
+	start := time.Now()
+	p.DeriveKey("calibration", salt)
+	elapsed := time.Since(start)
+	if elapsed <= 0 {
+		return p
+	}
+
+	iterations := uint32(float64(p.Time) * float64(target) / float64(elapsed))
+	if iterations > p.Time {
+		p.Time = min(iterations, calibrationMaxTime)
+	}
+	return p
+}
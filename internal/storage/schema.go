@@ -0,0 +1,157 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// 数据库结构版本记录在 metadata 的 schema_version 中。
+// 打开数据库时按顺序执行尚未应用的迁移，每个迁移与版本号更新在同一事务中提交；
+// 升级已有数据库前会在快照目录中留一份升级前的副本。
+// 新增迁移只能追加到 migrations 末尾，已发布的迁移不可修改。
+
+// migration 单个结构迁移
+type migration struct {
+	version     int
+	description string
+	apply       func(tx *sql.Tx) error
+}
+
+var migrations = []migration{
+	{1, "create tables", createTables},
+	{2, "account timestamps", addAccountTimestamps},
+	{3, "kdf params", recordLegacyKDF},
+}
+
+// currentSchemaVersion 当前程序使用的结构版本
+var currentSchemaVersion = migrations[len(migrations)-1].version
+
+// migrateSchema 将数据库升级到 currentSchemaVersion
+func migrateSchema(db *sql.DB, dbPath string) error {
+	version, err := schemaVersion(db, "main")
+	if err != nil {
+		return err
+	}
+	if version > currentSchemaVersion {
+		return fmt.Errorf("database schema version %d is newer than supported version %d", version, currentSchemaVersion)
+	}
+	if version == currentSchemaVersion {
+		return nil
+	}
+
+	// 已有数据库升级前先备份
+	if version > 0 {
+		dir := filepath.Join(filepath.Dir(dbPath), snapshotDirName)
+		if _, err := writeSnapshot(db, dir, SnapshotReasonMigrate); err != nil {
+			return fmt.Errorf("failed to back up database before migration: %w", err)
+		}
+	}
+
+	for _, m := range migrations {
+		if m.version <= version {
+			continue
+		}
+		tx, err := db.Begin()
+		if err != nil {
+			return fmt.Errorf("failed to begin migration: %w", err)
+		}
+		if err := m.apply(tx); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migration %d (%s) failed: %w", m.version, m.description, err)
+		}
+		if _, err := tx.Exec("INSERT OR REPLACE INTO metadata (key, value) VALUES ('schema_version', ?)",
+			strconv.Itoa(m.version)); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to save schema version: %w", err)
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("failed to commit migration %d: %w", m.version, err)
+		}
+	}
+	return nil
+}
+
+// schemaVersion 读取指定库（main 或 ATTACH 的别名）的结构版本
+// 没有 metadata 表视为空库（0），有表但没有版本号的是引入版本号之前的数据库（1）
+func schemaVersion(db querier, schema string) (int, error) {
+	var count int
+	err := db.QueryRow("SELECT COUNT(*) FROM " + schema + ".sqlite_master WHERE type = 'table' AND name = 'metadata'").Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read schema: %w", err)
+	}
+	if count == 0 {
+		return 0, nil
+	}
+
+	var value string
+	err = db.QueryRow("SELECT value FROM " + schema + ".metadata WHERE key = 'schema_version'").Scan(&value)
+	if err == sql.ErrNoRows {
+		return 1, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to read schema version: %w", err)
+	}
+	version, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid schema version: %s", value)
+	}
+	return version, nil
+}
+
+// createTables 版本 1：初始表结构
+func createTables(tx *sql.Tx) error {
+	schema := `
+	CREATE TABLE IF NOT EXISTS metadata (
+		key TEXT PRIMARY KEY,
+		value TEXT NOT NULL
+	);
+
+	CREATE TABLE IF NOT EXISTS accounts (
+		id TEXT PRIMARY KEY,
+		data TEXT NOT NULL
+	);
+
+	CREATE TABLE IF NOT EXISTS settings (
+		key TEXT PRIMARY KEY,
+		value TEXT NOT NULL
+	);
+	`
+
+	if _, err := tx.Exec(schema); err != nil {
+		return fmt.Errorf("failed to create tables: %w", err)
+	}
+	return nil
+}
+
+// addAccountTimestamps 版本 2：账户创建、修改时间（Unix 秒），已有账户以升级时间填充
+func addAccountTimestamps(tx *sql.Tx) error {
+	statements := []string{
+		"ALTER TABLE accounts ADD COLUMN created_at INTEGER NOT NULL DEFAULT 0",
+		"ALTER TABLE accounts ADD COLUMN updated_at INTEGER NOT NULL DEFAULT 0",
+	}
+	for _, stmt := range statements {
+		if _, err := tx.Exec(stmt); err != nil {
+			return err
+		}
+	}
+
+	now := time.Now().Unix()
+	_, err := tx.Exec("UPDATE accounts SET created_at = ?, updated_at = ?", now, now)
+	return err
+}
+
+// recordLegacyKDF 版本 3：为已启用密码的数据库写入当时固定使用的 KDF 参数
+func recordLegacyKDF(tx *sql.Tx) error {
+	var count int
+	if err := tx.QueryRow("SELECT COUNT(*) FROM metadata WHERE key = 'password_verifier'").Scan(&count); err != nil {
+		return err
+	}
+	if count == 0 {
+		return nil
+	}
+	legacy := legacyKDFParams()
+	return saveKDF(tx, &legacy)
+}
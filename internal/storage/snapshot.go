@@ -0,0 +1,274 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// 快照是数据库文件的完整副本（VACUUM INTO），与数据库使用同一密钥加密，
+// 在删除、改密、导入、恢复等破坏性操作前以及按计划自动生成，超出保留份数的旧快照会被删除。
+
+const (
+	snapshotPrefix     = "authenticator-"
+	snapshotExt        = ".db"
+	snapshotTimeLayout = "20060102-150405.000"
+	snapshotDirName    = "snapshots"
+
+	DefaultSnapshotKeep          = 10
+	DefaultSnapshotIntervalHours = 24
+)
+
+// 快照原因
+const (
+	SnapshotReasonManual    = "manual"
+	SnapshotReasonScheduled = "scheduled"
+	SnapshotReasonDelete    = "delete"
+	SnapshotReasonDeleteAll = "delete-all"
+	SnapshotReasonPassword  = "password"
+	SnapshotReasonImport    = "import"
+	SnapshotReasonRestore   = "restore"
+	SnapshotReasonMigrate   = "migrate"
+)
+
+// SnapshotInfo 快照文件信息
+type SnapshotInfo struct {
+	Name      string    `json:"name"`
+	Path      string    `json:"path"`
+	Reason    string    `json:"reason"`
+	CreatedAt time.Time `json:"created_at"`
+	Size      int64     `json:"size"`
+}
+
+// snapshotConfigLocked 返回快照目录与保留份数，未解锁时无法读取设置，使用默认值
+func (d *Database) snapshotConfigLocked() (string, int, int) {
+	settings := DefaultSettings()
+	if d.IsUnlocked() {
+		if s, err := d.getSettingsInternal(); err == nil {
+			settings = s
+		}
+	}
+
+	dir := settings.SnapshotDir
+	if dir == "" {
+		dir = filepath.Join(filepath.Dir(d.dbPath), snapshotDirName)
+	}
+	keep := settings.SnapshotKeep
+	if keep <= 0 {
+		keep = DefaultSnapshotKeep
+	}
+	interval := settings.SnapshotIntervalHours
+	if interval == 0 {
+		interval = DefaultSnapshotIntervalHours
+	}
+	return dir, keep, interval
+}
+
+// Snapshot 立即生成快照
+func (d *Database) Snapshot(reason string) (*SnapshotInfo, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return d.snapshotLocked(reason)
+}
+
+// snapshotLocked 生成快照并轮换旧快照，调用方需持有锁
+func (d *Database) snapshotLocked(reason string) (*SnapshotInfo, error) {
+	dir, keep, _ := d.snapshotConfigLocked()
+	info, err := writeSnapshot(d.db, dir, reason)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshots, err := listSnapshots(dir)
+	if err == nil {
+		for _, s := range snapshots[min(keep, len(snapshots)):] {
+			os.Remove(s.Path)
+		}
+	}
+	return info, nil
+}
+
+// writeSnapshot 将数据库完整复制到快照目录
+func writeSnapshot(db *sql.DB, dir, reason string) (*SnapshotInfo, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create snapshot directory: %w", err)
+	}
+
+	now := time.Now()
+	name := snapshotPrefix + now.Format(snapshotTimeLayout) + "-" + reason + snapshotExt
+	path := filepath.Join(dir, name)
+	if _, err := db.Exec("VACUUM INTO ?", path); err != nil {
+		return nil, fmt.Errorf("failed to create snapshot: %w", err)
+	}
+	os.Chmod(path, 0600)
+
+	info := &SnapshotInfo{Name: name, Path: path, Reason: reason, CreatedAt: now}
+	if fi, err := os.Stat(path); err == nil {
+		info.Size = fi.Size()
+	}
+	return info, nil
+}
+
+// ListSnapshots 列出快照，按时间从新到旧排列
+func (d *Database) ListSnapshots() ([]SnapshotInfo, error) {
+	d.mu.Lock()
+	dir, _, _ := d.snapshotConfigLocked()
+	d.mu.Unlock()
+
+	return listSnapshots(dir)
+}
+
+// listSnapshots 读取目录中的快照文件，不存在的目录视为空
+func listSnapshots(dir string) ([]SnapshotInfo, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []SnapshotInfo{}, nil
+		}
+		return nil, fmt.Errorf("failed to read snapshot directory: %w", err)
+	}
+
+	snapshots := []SnapshotInfo{}
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasPrefix(name, snapshotPrefix) || !strings.HasSuffix(name, snapshotExt) {
+			continue
+		}
+		stem := strings.TrimSuffix(strings.TrimPrefix(name, snapshotPrefix), snapshotExt)
+		if len(stem) < len(snapshotTimeLayout)+2 {
+			continue
+		}
+		createdAt, err := time.ParseInLocation(snapshotTimeLayout, stem[:len(snapshotTimeLayout)], time.Local)
+		if err != nil {
+			continue
+		}
+
+		info := SnapshotInfo{
+			Name:      name,
+			Path:      filepath.Join(dir, name),
+			Reason:    stem[len(snapshotTimeLayout)+1:],
+			CreatedAt: createdAt,
+		}
+		if fi, err := e.Info(); err == nil {
+			info.Size = fi.Size()
+		}
+		snapshots = append(snapshots, info)
+	}
+
+	sort.Slice(snapshots, func(i, j int) bool {
+		return snapshots[i].CreatedAt.After(snapshots[j].CreatedAt)
+	})
+	return snapshots, nil
+}
+
+// SnapshotIfDue 距最近一次快照超过设定间隔时生成定时快照，返回是否生成
+func (d *Database) SnapshotIfDue() (bool, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if !d.IsUnlocked() {
+		return false, nil
+	}
+	dir, _, interval := d.snapshotConfigLocked()
+	if interval < 0 {
+		return false, nil
+	}
+
+	snapshots, err := listSnapshots(dir)
+	if err != nil {
+		return false, err
+	}
+	if len(snapshots) > 0 && time.Since(snapshots[0].CreatedAt) < time.Duration(interval)*time.Hour {
+		return false, nil
+	}
+
+	if _, err := d.snapshotLocked(SnapshotReasonScheduled); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// RestoreSnapshot 用指定快照替换数据库内容，恢复前会先为当前状态生成快照
+// 快照中的密码设置随之恢复，恢复后数据库处于锁定状态，有密码时需重新解锁
+func (d *Database) RestoreSnapshot(name string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if name != filepath.Base(name) || !strings.HasPrefix(name, snapshotPrefix) || !strings.HasSuffix(name, snapshotExt) {
+		return fmt.Errorf("invalid snapshot name: %s", name)
+	}
+	dir, _, _ := d.snapshotConfigLocked()
+	path := filepath.Join(dir, name)
+	if _, err := os.Stat(path); err != nil {
+		return fmt.Errorf("snapshot not found: %s", name)
+	}
+
+	if _, err := d.snapshotLocked(SnapshotReasonRestore); err != nil {
+		return err
+	}
+
+	// ATTACH 只对当前连接有效，且不能在事务中执行
+	ctx := context.Background()
+	conn, err := d.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get connection: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, "ATTACH DATABASE ? AS snap", path); err != nil {
+		return fmt.Errorf("failed to open snapshot: %w", err)
+	}
+	defer conn.ExecContext(ctx, "DETACH DATABASE snap")
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var count int
+	if err := tx.QueryRow("SELECT COUNT(*) FROM snap.metadata WHERE key = 'salt'").Scan(&count); err != nil || count == 0 {
+		return fmt.Errorf("invalid snapshot: %s", name)
+	}
+
+	// 快照可能来自旧结构版本：结构版本号保留当前值，旧快照没有的列使用默认值
+	version, err := schemaVersion(tx, "snap")
+	if err != nil {
+		return err
+	}
+	if version > currentSchemaVersion {
+		return fmt.Errorf("snapshot schema version %d is newer than supported version %d", version, currentSchemaVersion)
+	}
+	copyAccounts := "INSERT INTO main.accounts (id, data) SELECT id, data FROM snap.accounts"
+	if version >= 2 {
+		copyAccounts = `INSERT INTO main.accounts (id, data, created_at, updated_at)
+			SELECT id, data, created_at, updated_at FROM snap.accounts`
+	}
+
+	statements := []string{
+		"DELETE FROM main.metadata WHERE key != 'schema_version'",
+		"INSERT INTO main.metadata (key, value) SELECT key, value FROM snap.metadata WHERE key != 'schema_version'",
+		"DELETE FROM main.accounts",
+		copyAccounts,
+		"DELETE FROM main.settings",
+		"INSERT INTO main.settings (key, value) SELECT key, value FROM snap.settings",
+	}
+	for _, stmt := range statements {
+		if _, err := tx.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("failed to restore snapshot: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to restore snapshot: %w", err)
+	}
+
+	// 快照可能使用不同的密钥，清除当前主密钥
+	d.masterKey = nil
+	return nil
+}
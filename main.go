@@ -8,6 +8,7 @@ import (
 	"strings"
 
 	"google-authenticator/internal/platform"
+	"google-authenticator/internal/storage"
 	"google-authenticator/internal/tray"
 
 	"github.com/wailsapp/wails/v2"
@@ -25,26 +26,48 @@ var assets embed.FS
 var app *App
 var lockFilePath string
 
+// parseDataDirOptions 在全部参数中查找 --data-dir 和 --portable，忽略其他参数
+// 不使用 flag 包：它在第一个无法识别的参数（如 macOS 的 -psn_… 或开发模式参数）处停止解析
+func parseDataDirOptions(args []string) storage.DataDirOptions {
+	var opts storage.DataDirOptions
+	for i := 0; i < len(args); i++ {
+		if !strings.HasPrefix(args[i], "-") {
+			continue
+		}
+		name, value, hasValue := strings.Cut(strings.TrimLeft(args[i], "-"), "=")
+		switch name {
+		case "data-dir":
+			if !hasValue && i+1 < len(args) {
+				i++
+				value = args[i]
+			}
+			opts.DataDir = value
+		case "portable":
+			opts.Portable = true
+			if hasValue {
+				opts.Portable, _ = strconv.ParseBool(value)
+			}
+		}
+	}
+	return opts
+}
+
 // getLockFilePath 获取锁文件路径
-func getLockFilePath() string {
-	execPath, err := os.Executable()
-	if err != nil {
+func getLockFilePath(dataDir string) string {
+	if dataDir == "" {
 		return ""
 	}
-	execDir := filepath.Dir(execPath)
-	dataDir := filepath.Join(execDir, "data")
 	return filepath.Join(dataDir, ".lock")
 }
 
-// acquireLock 获取单实例锁
-func acquireLock() bool {
-	lockFilePath = getLockFilePath()
+// acquireLock 获取单实例锁，每个数据目录只允许一个实例
+func acquireLock(dataDir string) bool {
+	lockFilePath = getLockFilePath(dataDir)
 	if lockFilePath == "" {
 		return true // 无法获取路径，允许启动
 	}
 
-	// 确保 data 目录存在
-	dataDir := filepath.Dir(lockFilePath)
+	// 确保数据目录存在
 	if err := os.MkdirAll(dataDir, 0700); err != nil {
 		return true // 无法创建目录，允许启动
 	}
@@ -86,14 +109,21 @@ func releaseLock() {
 }
 
 func main() {
+	// 确定数据目录
+	dataDir, err := storage.ResolveDataDir(parseDataDirOptions(os.Args[1:]))
+	if err != nil {
+		platform.ShowMessage("Google Authenticator", "无法确定数据目录："+err.Error())
+		os.Exit(1)
+	}
+
 	// 单实例检测
-	if !acquireLock() {
+	if !acquireLock(dataDir) {
 		platform.ShowMessage("Google Authenticator", "程序已在运行中，请检查系统托盘。")
 		os.Exit(0)
 	}
 	defer releaseLock()
 
-	app = NewApp()
+	app = NewApp(dataDir)
 
 	appMenu := menu.NewMenu()
 
@@ -140,7 +170,7 @@ func main() {
 		runtime.EventsEmit(app.ctx, "menu:about")
 	})
 
-	err := wails.Run(&options.App{
+	err = wails.Run(&options.App{
 		Title:  "Google Authenticator",
 		Width:  1024,
 		Height: 768,